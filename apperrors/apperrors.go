@@ -0,0 +1,181 @@
+package apperrors
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Kind classifies an error by what the client can do about it. It is sent
+// as the response's code, so the values are part of the API and must not
+// change.
+type Kind string
+
+const (
+	KindBadRequest      Kind = "bad_request"
+	KindValidation      Kind = "validation_failed"
+	KindUnauthorized    Kind = "unauthorized"
+	KindForbidden       Kind = "forbidden"
+	KindNotFound        Kind = "not_found"
+	KindConflict        Kind = "conflict"
+	KindTooManyRequests Kind = "too_many_requests"
+	KindInternal        Kind = "internal_error"
+)
+
+// FieldError is one problem with one field of a request body.
+type FieldError struct {
+	Field   string `json:"field" example:"price"`
+	Rule    string `json:"rule" example:"required"`
+	Message string `json:"message" example:"price is required"`
+}
+
+// Error is an error a handler hands to c.Error for the error middleware to
+// turn into a response. Message and Details are shown to the client; Err is
+// the cause, which is only logged.
+type Error struct {
+	Kind    Kind
+	Message string
+	Details []FieldError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrorResponse is the body of every error response.
+type ErrorResponse struct {
+	Error   string       `json:"error" example:"Food item was not found"`
+	Code    Kind         `json:"code" example:"not_found"`
+	Details []FieldError `json:"details,omitempty"`
+}
+
+func (e *Error) Response() ErrorResponse {
+	return ErrorResponse{Error: e.Message, Code: e.Kind, Details: e.Details}
+}
+
+func BadRequest(message string) *Error {
+	return &Error{Kind: KindBadRequest, Message: message}
+}
+
+func Validation(message string) *Error {
+	return &Error{Kind: KindValidation, Message: message}
+}
+
+func Unauthorized(message string) *Error {
+	return &Error{Kind: KindUnauthorized, Message: message}
+}
+
+func Forbidden(message string) *Error {
+	return &Error{Kind: KindForbidden, Message: message}
+}
+
+func NotFound(message string) *Error {
+	return &Error{Kind: KindNotFound, Message: message}
+}
+
+func Conflict(message string) *Error {
+	return &Error{Kind: KindConflict, Message: message}
+}
+
+func TooManyRequests(message string) *Error {
+	return &Error{Kind: KindTooManyRequests, Message: message}
+}
+
+func Internal(message string, err error) *Error {
+	return &Error{Kind: KindInternal, Message: message, Err: err}
+}
+
+// ErrNoChanges is passed to Invalid for an update whose body sets no field
+// that can be changed.
+var ErrNoChanges = errors.New("no fields to update")
+
+// Invalid describes a request body that could not be bound or failed struct
+// validation, with one detail per offending field when the error says which.
+func Invalid(err error) *Error {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		details := make([]FieldError, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			field := strings.ToLower(fieldErr.Field())
+			details = append(details, FieldError{
+				Field:   field,
+				Rule:    fieldErr.Tag(),
+				Message: ruleMessage(field, fieldErr),
+			})
+		}
+		return &Error{Kind: KindValidation, Message: "Request validation failed", Details: details, Err: err}
+	}
+
+	if errors.Is(err, ErrNoChanges) {
+		return &Error{Kind: KindValidation, Message: "Request body does not set any field that can be updated", Err: err}
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return &Error{
+			Kind:    KindValidation,
+			Message: "Request validation failed",
+			Details: []FieldError{{Field: typeErr.Field, Rule: "type", Message: typeErr.Field + " must be a " + typeErr.Type.String()}},
+			Err:     err,
+		}
+	}
+
+	return &Error{Kind: KindValidation, Message: "Request body is not valid JSON", Err: err}
+}
+
+func ruleMessage(field string, fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return field + " is required"
+	case "min", "gte":
+		return field + " must be at least " + fieldErr.Param()
+	case "max", "lte":
+		return field + " must be at most " + fieldErr.Param()
+	case "email":
+		return field + " must be an e-mail address"
+	case "oneof":
+		return field + " must be one of " + fieldErr.Param()
+	default:
+		return field + " is not valid (" + fieldErr.Tag() + ")"
+	}
+}
+
+// As returns err as an *Error, treating anything that is not one as an
+// internal error.
+func As(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return Internal("Internal server error", err)
+}
+
+func Status(kind Kind) int {
+	switch kind {
+	case KindBadRequest, KindValidation:
+		return http.StatusBadRequest
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindTooManyRequests:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
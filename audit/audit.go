@@ -0,0 +1,90 @@
+package audit
+
+import (
+	"context"
+	"golang-restaurant-management/models"
+	"golang-restaurant-management/repository"
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	EntityFood      = "food"
+	EntityMenu      = "menu"
+	EntityTable     = "table"
+	EntityOrder     = "order"
+	EntityOrderItem = "order_item"
+	EntityInvoice   = "invoice"
+	EntityUser      = "user"
+	EntityTerminal  = "terminal"
+	EntityApiKey    = "api_key"
+)
+
+// Recorder writes audit entries to the audit log repository it was created
+// with.
+type Recorder struct {
+	auditLogs repository.AuditLogRepository
+}
+
+func NewRecorder(auditLogs repository.AuditLogRepository) *Recorder {
+	return &Recorder{auditLogs: auditLogs}
+}
+
+// Record stores who changed which entity through which route, together with
+// the document before and after the change. before is nil for creations.
+// Failures are logged rather than returned: a missing audit entry must not
+// undo a change that has already been written.
+func (r *Recorder) Record(c *gin.Context, entity, entityId string, before, after interface{}) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+	defer cancel()
+
+	entry := models.AuditLog{
+		ID:         primitive.NewObjectID(),
+		Actor_id:   c.GetString("uid"),
+		Actor_role: c.GetString("role"),
+		Method:     c.Request.Method,
+		Route:      c.FullPath(),
+		Entity:     entity,
+		Entity_id:  entityId,
+		Before:     toDocument(before),
+		After:      toDocument(after),
+		Ip_address: c.ClientIP(),
+		Created_at: time.Now(),
+	}
+	entry.Audit_id = entry.ID.Hex()
+
+	if err := r.auditLogs.Create(ctx, entry); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error recording audit log", "entity", entity, "entity_id", entityId, "error", err)
+	}
+}
+
+// toDocument round-trips a model through BSON so every snapshot is stored and
+// read back as a plain document.
+func toDocument(value interface{}) bson.M {
+	if value == nil {
+		return nil
+	}
+
+	if document, ok := value.(bson.M); ok {
+		return document
+	}
+
+	data, err := bson.Marshal(value)
+	if err != nil {
+		slog.Error("Error encoding audit snapshot", "error", err)
+		return nil
+	}
+
+	var document bson.M
+	if err := bson.Unmarshal(data, &document); err != nil {
+		slog.Error("Error decoding audit snapshot", "error", err)
+		return nil
+	}
+
+	delete(document, "_id")
+	return document
+}
@@ -0,0 +1,331 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every setting the server reads at startup. Values are layered
+// in this order, later sources winning: built-in defaults, the YAML file named
+// by -config or CONFIG_FILE, environment variables (including a .env file),
+// and command-line flags.
+type Config struct {
+	Env                      string  `yaml:"env"`
+	LogLevel                 string  `yaml:"log_level"`
+	Port                     string  `yaml:"port"`
+	AppBaseURL               string  `yaml:"app_base_url"`
+	RequireEmailVerification bool    `yaml:"require_email_verification"`
+	Server                   Server  `yaml:"server"`
+	Mongo                    Mongo   `yaml:"mongo"`
+	JWT                      JWT     `yaml:"jwt"`
+	Mailer                   Mailer  `yaml:"mailer"`
+	Tracing                  Tracing `yaml:"tracing"`
+}
+
+// Server holds the HTTP server timeouts. ShutdownTimeout bounds how long a
+// SIGTERM waits for in-flight requests and shutdown hooks.
+type Server struct {
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+type Mongo struct {
+	URI      string `yaml:"uri"`
+	Database string `yaml:"database"`
+}
+
+// JWT configures token signing. When KeyDir is set tokens are signed with
+// RS256 from the keys in that directory, otherwise with HS256 and SecretKey.
+type JWT struct {
+	SecretKey       string        `yaml:"secret_key"`
+	KeyDir          string        `yaml:"key_dir"`
+	SigningKeyID    string        `yaml:"signing_key_id"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+}
+
+// Mailer selects how account e-mails are sent: "log" (default) or "file" for
+// development, or "smtp" through SMTPHost:SMTPPort, which production requires.
+type Mailer struct {
+	Driver       string `yaml:"driver"`
+	FilePath     string `yaml:"file_path"`
+	SMTPHost     string `yaml:"smtp_host"`
+	SMTPPort     string `yaml:"smtp_port"`
+	SMTPUsername string `yaml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password"`
+	From         string `yaml:"from"`
+}
+
+// Tracing selects where spans go: "none" (default), "stdout", or "otlp", which
+// sends them over OTLP/HTTP to Endpoint (host:port, e.g. localhost:4318).
+type Tracing struct {
+	Exporter    string  `yaml:"exporter"`
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`
+	ServiceName string  `yaml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+func Default() Config {
+	return Config{
+		Env:      "development",
+		LogLevel: "info",
+		Port:     "8000",
+		Server: Server{
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 20 * time.Second,
+		},
+		Mongo: Mongo{
+			Database: "restaurant",
+		},
+		JWT: JWT{
+			AccessTokenTTL:  time.Hour,
+			RefreshTokenTTL: 30 * 24 * time.Hour,
+		},
+		Mailer: Mailer{
+			Driver:   "log",
+			FilePath: "mail.log",
+			SMTPPort: "587",
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			Endpoint:    "localhost:4318",
+			Insecure:    true,
+			ServiceName: "restaurant-management",
+			SampleRatio: 1,
+		},
+	}
+}
+
+// Load builds the configuration from the given command-line arguments
+// (without the program name) and the environment, and validates the result.
+func Load(args []string) (Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("Error loading .env file", "error", err)
+	}
+
+	flags := flag.NewFlagSet("restaurant", flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML configuration file")
+	port := flags.String("port", "", "port the HTTP server listens on")
+	mongoURI := flags.String("mongo-uri", "", "MongoDB connection URI")
+	dbName := flags.String("db-name", "", "MongoDB database name")
+	if err := flags.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	cfg := Default()
+
+	if *configFile != "" {
+		data, err := os.ReadFile(*configFile)
+		if err != nil {
+			return Config{}, fmt.Errorf("reading config file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return Config{}, fmt.Errorf("parsing config file %s: %w", *configFile, err)
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return Config{}, err
+	}
+
+	if *port != "" {
+		cfg.Port = *port
+	}
+	if *mongoURI != "" {
+		cfg.Mongo.URI = *mongoURI
+	}
+	if *dbName != "" {
+		cfg.Mongo.Database = *dbName
+	}
+
+	// links in development e-mails point at the local server unless told
+	// otherwise; production must name its public URL
+	if cfg.AppBaseURL == "" && cfg.Env == "development" {
+		cfg.AppBaseURL = "http://localhost:" + cfg.Port
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+func (cfg *Config) applyEnv() error {
+	setString(&cfg.Env, "ENV")
+	setString(&cfg.LogLevel, "LOG_LEVEL")
+	setString(&cfg.Port, "PORT")
+	setString(&cfg.AppBaseURL, "APP_BASE_URL")
+	setString(&cfg.Mongo.URI, "MONGO_URI")
+	setString(&cfg.Mongo.Database, "DB_NAME")
+	setString(&cfg.JWT.SecretKey, "SECRET_KEY")
+	setString(&cfg.JWT.KeyDir, "JWT_KEY_DIR")
+	setString(&cfg.JWT.SigningKeyID, "JWT_SIGNING_KEY_ID")
+	setString(&cfg.Mailer.Driver, "MAILER")
+	setString(&cfg.Mailer.FilePath, "MAILER_FILE_PATH")
+	setString(&cfg.Mailer.SMTPHost, "MAILER_SMTP_HOST")
+	setString(&cfg.Mailer.SMTPPort, "MAILER_SMTP_PORT")
+	setString(&cfg.Mailer.SMTPUsername, "MAILER_SMTP_USERNAME")
+	setString(&cfg.Mailer.SMTPPassword, "MAILER_SMTP_PASSWORD")
+	setString(&cfg.Mailer.From, "MAILER_FROM")
+	setString(&cfg.Tracing.Exporter, "TRACING_EXPORTER")
+	setString(&cfg.Tracing.Endpoint, "TRACING_ENDPOINT")
+	setString(&cfg.Tracing.ServiceName, "TRACING_SERVICE_NAME")
+
+	if value := os.Getenv("REQUIRE_EMAIL_VERIFICATION"); value != "" {
+		required, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("REQUIRE_EMAIL_VERIFICATION: %w", err)
+		}
+		cfg.RequireEmailVerification = required
+	}
+
+	if value := os.Getenv("TRACING_INSECURE"); value != "" {
+		insecure, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("TRACING_INSECURE: %w", err)
+		}
+		cfg.Tracing.Insecure = insecure
+	}
+
+	if value := os.Getenv("TRACING_SAMPLE_RATIO"); value != "" {
+		ratio, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("TRACING_SAMPLE_RATIO: %w", err)
+		}
+		cfg.Tracing.SampleRatio = ratio
+	}
+
+	durations := []struct {
+		target *time.Duration
+		key    string
+	}{
+		{&cfg.Server.ReadTimeout, "SERVER_READ_TIMEOUT"},
+		{&cfg.Server.WriteTimeout, "SERVER_WRITE_TIMEOUT"},
+		{&cfg.Server.IdleTimeout, "SERVER_IDLE_TIMEOUT"},
+		{&cfg.Server.ShutdownTimeout, "SERVER_SHUTDOWN_TIMEOUT"},
+		{&cfg.JWT.AccessTokenTTL, "ACCESS_TOKEN_TTL"},
+		{&cfg.JWT.RefreshTokenTTL, "REFRESH_TOKEN_TTL"},
+	}
+	for _, duration := range durations {
+		if err := setDuration(duration.target, duration.key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate reports every problem with the configuration at once, so a
+// misconfigured deployment fails on startup instead of on the first request.
+func (cfg Config) Validate() error {
+	var problems []string
+
+	switch cfg.Env {
+	case "development", "production":
+	default:
+		problems = append(problems, "env must be development or production")
+	}
+	switch strings.ToLower(cfg.LogLevel) {
+	case "debug", "info", "warn", "error":
+	default:
+		problems = append(problems, "log_level must be debug, info, warn or error")
+	}
+	if port, err := strconv.Atoi(cfg.Port); err != nil || port < 1 || port > 65535 {
+		problems = append(problems, "port must be a number between 1 and 65535")
+	}
+	if baseURL, err := url.Parse(cfg.AppBaseURL); cfg.AppBaseURL == "" || err != nil || (baseURL.Scheme != "http" && baseURL.Scheme != "https") || baseURL.Host == "" {
+		problems = append(problems, "app_base_url (APP_BASE_URL) must be an absolute http or https URL")
+	}
+	if cfg.Server.ReadTimeout <= 0 || cfg.Server.WriteTimeout <= 0 || cfg.Server.IdleTimeout <= 0 {
+		problems = append(problems, "server read, write and idle timeouts must be positive")
+	}
+	if cfg.Server.ShutdownTimeout <= 0 {
+		problems = append(problems, "server shutdown_timeout must be positive")
+	}
+	if cfg.Mongo.URI == "" {
+		problems = append(problems, "mongo uri (MONGO_URI) is required")
+	}
+	if cfg.Mongo.Database == "" {
+		problems = append(problems, "mongo database (DB_NAME) is required")
+	}
+	if cfg.JWT.SecretKey == "" && cfg.JWT.KeyDir == "" {
+		problems = append(problems, "either jwt secret_key (SECRET_KEY) or key_dir (JWT_KEY_DIR) is required")
+	}
+	if cfg.JWT.AccessTokenTTL <= 0 {
+		problems = append(problems, "jwt access_token_ttl must be positive")
+	}
+	if cfg.JWT.RefreshTokenTTL < cfg.JWT.AccessTokenTTL {
+		problems = append(problems, "jwt refresh_token_ttl must not be shorter than access_token_ttl")
+	}
+	switch strings.ToLower(cfg.Mailer.Driver) {
+	case "log", "file":
+		// both write verification links and reset codes in plain text
+		if cfg.Env == "production" {
+			problems = append(problems, "mailer driver must be smtp in production")
+		}
+		if strings.EqualFold(cfg.Mailer.Driver, "file") && cfg.Mailer.FilePath == "" {
+			problems = append(problems, "mailer file_path is required for the file mailer")
+		}
+	case "smtp":
+		if cfg.Mailer.SMTPHost == "" || cfg.Mailer.From == "" {
+			problems = append(problems, "mailer smtp_host and from are required for the smtp mailer")
+		}
+		if port, err := strconv.Atoi(cfg.Mailer.SMTPPort); err != nil || port < 1 || port > 65535 {
+			problems = append(problems, "mailer smtp_port must be a number between 1 and 65535")
+		}
+	default:
+		problems = append(problems, "mailer driver must be log, file or smtp")
+	}
+	switch strings.ToLower(cfg.Tracing.Exporter) {
+	case "none", "stdout":
+	case "otlp":
+		if cfg.Tracing.Endpoint == "" {
+			problems = append(problems, "tracing endpoint is required for the otlp exporter")
+		}
+	default:
+		problems = append(problems, "tracing exporter must be none, stdout or otlp")
+	}
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		problems = append(problems, "tracing sample_ratio must be between 0 and 1")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func setString(target *string, key string) {
+	if value := os.Getenv(key); value != "" {
+		*target = value
+	}
+}
+
+func setDuration(target *time.Duration, key string) error {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	*target = duration
+	return nil
+}
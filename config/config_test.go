@@ -0,0 +1,78 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func validConfig() Config {
+	cfg := Default()
+	cfg.AppBaseURL = "http://localhost:8000"
+	cfg.Mongo.URI = "mongodb://localhost:27017"
+	cfg.JWT.SecretKey = "test-secret"
+	return cfg
+}
+
+func TestValidateMailerAndBaseURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(cfg *Config)
+		wantErr string
+	}{
+		{name: "development with the log mailer", modify: func(cfg *Config) {}},
+		{
+			name: "production with the smtp mailer",
+			modify: func(cfg *Config) {
+				cfg.Env = "production"
+				cfg.AppBaseURL = "https://restaurant.example.com"
+				cfg.Mailer = Mailer{Driver: "smtp", SMTPHost: "smtp.example.com", SMTPPort: "587", From: "no-reply@example.com"}
+			},
+		},
+		{
+			name:    "production with the log mailer",
+			modify:  func(cfg *Config) { cfg.Env = "production" },
+			wantErr: "mailer driver must be smtp in production",
+		},
+		{
+			name: "production with the file mailer",
+			modify: func(cfg *Config) {
+				cfg.Env = "production"
+				cfg.Mailer.Driver = "file"
+			},
+			wantErr: "mailer driver must be smtp in production",
+		},
+		{
+			name:    "smtp mailer without a host",
+			modify:  func(cfg *Config) { cfg.Mailer = Mailer{Driver: "smtp", SMTPPort: "587", From: "no-reply@example.com"} },
+			wantErr: "smtp_host and from are required",
+		},
+		{
+			name:    "missing base URL",
+			modify:  func(cfg *Config) { cfg.AppBaseURL = "" },
+			wantErr: "app_base_url",
+		},
+		{
+			name:    "relative base URL",
+			modify:  func(cfg *Config) { cfg.AppBaseURL = "restaurant.example.com" },
+			wantErr: "app_base_url",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate = %v, want an error mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
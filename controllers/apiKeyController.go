@@ -0,0 +1,225 @@
+package controller
+
+import (
+	"context"
+	"golang-restaurant-management/apperrors"
+	"golang-restaurant-management/audit"
+	"golang-restaurant-management/helper"
+	"golang-restaurant-management/models"
+	"golang-restaurant-management/query"
+	"golang-restaurant-management/repository"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ApiKeyController serves the admin endpoints that manage API keys.
+type ApiKeyController struct {
+	apiKeys  repository.ApiKeyRepository
+	recorder *audit.Recorder
+}
+
+func NewApiKeyController(apiKeys repository.ApiKeyRepository, recorder *audit.Recorder) *ApiKeyController {
+	return &ApiKeyController{apiKeys: apiKeys, recorder: recorder}
+}
+
+// ApiKeyView is the audit-safe form of an API key, without its key hash.
+type ApiKeyView struct {
+	Api_key_id   string     `json:"api_key_id"`
+	Name         *string    `json:"name"`
+	Prefix       string     `json:"prefix"`
+	Role         *string    `json:"role"`
+	Scopes       []string   `json:"scopes"`
+	Created_by   string     `json:"created_by"`
+	Created_at   time.Time  `json:"created_at"`
+	Expires_at   *time.Time `json:"expires_at"`
+	Last_used_at *time.Time `json:"last_used_at"`
+	Revoked_at   *time.Time `json:"revoked_at"`
+}
+
+func newApiKeyView(apiKey models.ApiKey) ApiKeyView {
+	return ApiKeyView{
+		Api_key_id:   apiKey.Api_key_id,
+		Name:         apiKey.Name,
+		Prefix:       apiKey.Prefix,
+		Role:         apiKey.Role,
+		Scopes:       apiKey.Scopes,
+		Created_by:   apiKey.Created_by,
+		Created_at:   apiKey.Created_at,
+		Expires_at:   apiKey.Expires_at,
+		Last_used_at: apiKey.Last_used_at,
+		Revoked_at:   apiKey.Revoked_at,
+	}
+}
+
+var apiKeyScopeResources = []string{
+	models.ScopeFoods,
+	models.ScopeMenus,
+	models.ScopeTables,
+	models.ScopeOrders,
+	models.ScopeOrderItems,
+	models.ScopeInvoices,
+}
+
+// @Summary Create an API key
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param body body models.ApiKey true "API key"
+// @Success 201 {object} ApiKeyCreatedResponse
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/api-keys [post]
+func (ac *ApiKeyController) CreateApiKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var apiKey models.ApiKey
+
+		if err := c.ShouldBindJSON(&apiKey); err != nil {
+			c.Error(apperrors.Invalid(err))
+			return
+		}
+
+		var validate = validator.New()
+		if validationErr := validate.Struct(apiKey); validationErr != nil {
+			c.Error(apperrors.Invalid(validationErr))
+			return
+		}
+
+		for _, scope := range apiKey.Scopes {
+			if !validApiKeyScope(scope) {
+				c.Error(apperrors.BadRequest("Invalid scope " + scope))
+				return
+			}
+		}
+
+		if apiKey.Expires_at != nil && apiKey.Expires_at.Before(time.Now()) {
+			c.Error(apperrors.BadRequest("expires_at must be in the future"))
+			return
+		}
+
+		key, prefix, hash, err := helper.GenerateApiKey()
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to generate API key", err))
+			return
+		}
+
+		apiKey.ID = primitive.NewObjectID()
+		apiKey.Api_key_id = apiKey.ID.Hex()
+		apiKey.Prefix = prefix
+		apiKey.Key_hash = hash
+		apiKey.Created_by = c.GetString("uid")
+		apiKey.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		apiKey.Last_used_at = nil
+		apiKey.Revoked_at = nil
+
+		if err := ac.apiKeys.Create(c.Request.Context(), apiKey); err != nil {
+			c.Error(apperrors.Internal("API key was not created", err))
+			return
+		}
+
+		ac.recorder.Record(c, audit.EntityApiKey, apiKey.Api_key_id, nil, newApiKeyView(apiKey))
+
+		// the plain key is handed to the integration and never shown again
+		c.JSON(http.StatusCreated, ApiKeyCreatedResponse{
+			Api_key_id: apiKey.Api_key_id,
+			Name:       apiKey.Name,
+			Prefix:     apiKey.Prefix,
+			Role:       apiKey.Role,
+			Scopes:     apiKey.Scopes,
+			Expires_at: apiKey.Expires_at,
+			Api_key:    key,
+		})
+	}
+}
+
+// @Summary List active API keys
+// @Tags api-keys
+// @Produce json
+// @Success 200 {object} query.Page[models.ApiKey]
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/api-keys [get]
+func (ac *ApiKeyController) GetApiKeys() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKeys, err := ac.apiKeys.ListActive(c.Request.Context())
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while fetching API keys", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, query.Whole(apiKeys))
+	}
+}
+
+// @Summary Revoke an API key
+// @Tags api-keys
+// @Produce json
+// @Param api_key_id path string true "API key ID"
+// @Success 200 {object} MessageResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/api-keys/{api_key_id} [delete]
+func (ac *ApiKeyController) RevokeApiKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		apiKeyId := c.Param("api_key_id")
+
+		before := ac.apiKeySnapshot(ctx, apiKeyId)
+
+		revoked, err := ac.apiKeys.Revoke(ctx, apiKeyId, time.Now())
+		if err != nil {
+			c.Error(apperrors.Internal("API key was not revoked", err))
+			return
+		}
+
+		if !revoked {
+			c.Error(apperrors.NotFound("API key not found"))
+			return
+		}
+
+		ac.recorder.Record(c, audit.EntityApiKey, apiKeyId, before, ac.apiKeySnapshot(ctx, apiKeyId))
+
+		c.JSON(http.StatusOK, MessageResponse{Message: "API key revoked"})
+	}
+}
+
+// apiKeySnapshot returns the audit-safe view of an API key, or nil when the
+// key does not exist.
+func (ac *ApiKeyController) apiKeySnapshot(ctx context.Context, apiKeyId string) interface{} {
+	apiKey, err := ac.apiKeys.FindByID(ctx, apiKeyId)
+	if err != nil {
+		return nil
+	}
+
+	return newApiKeyView(apiKey)
+}
+
+// validApiKeyScope accepts "<resource>:read", "<resource>:write" and
+// "<resource>:*" for the resources an API key can be given.
+func validApiKeyScope(scope string) bool {
+	resource, action, found := strings.Cut(scope, ":")
+	if !found || (action != "read" && action != "write" && action != "*") {
+		return false
+	}
+
+	for _, allowed := range apiKeyScopeResources {
+		if resource == allowed {
+			return true
+		}
+	}
+	return false
+}
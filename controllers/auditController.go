@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"context"
+	"golang-restaurant-management/query"
+	"golang-restaurant-management/repository"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditController struct {
+	auditLogs repository.AuditLogRepository
+}
+
+func NewAuditController(auditLogs repository.AuditLogRepository) *AuditController {
+	return &AuditController{auditLogs: auditLogs}
+}
+
+// auditLogListSpec filters audit entries by entity, entity_id, user_id (the
+// actor) and a created_at range.
+var auditLogListSpec = query.Spec{
+	Filters: []query.FilterSpec{
+		{Param: "entity", Field: "entity", Type: query.String},
+		{Param: "entity_id", Field: "entity_id", Type: query.String},
+		{Param: "user_id", Field: "actor_id", Type: query.String},
+		{Param: "created_at", Field: "created_at", Type: query.Time},
+	},
+	Sorts:        []string{"created_at"},
+	DefaultSort:  "-created_at",
+	DefaultLimit: 50,
+}
+
+// GetAuditLogs lists audit entries, newest first.
+//
+// @Summary List audit log entries
+// @Tags audit-logs
+// @Produce json
+// @Param limit query int false "Items per page"
+// @Param offset query int false "Items to skip"
+// @Param page query int false "Page number, from 1"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Comma-separated fields, - for descending"
+// @Param entity query string false "Entity type"
+// @Param entity_id query string false "Entity ID"
+// @Param user_id query string false "ID of the user who made the change"
+// @Param created_at_from query string false "Earliest creation time, RFC 3339"
+// @Param created_at_to query string false "Latest creation time, RFC 3339"
+// @Success 200 {object} query.Page[models.AuditLog]
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/audit-logs [get]
+func (ac *AuditController) GetAuditLogs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		params, ok := listParams(c, auditLogListSpec)
+		if !ok {
+			return
+		}
+
+		page, err := ac.auditLogs.List(ctx, params)
+		if err != nil {
+			c.Error(listError(err, "Error occurred while fetching audit logs"))
+			return
+		}
+
+		c.JSON(http.StatusOK, page)
+	}
+}
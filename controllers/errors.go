@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"errors"
+
+	"golang-restaurant-management/apperrors"
+	"golang-restaurant-management/query"
+	"golang-restaurant-management/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// lookupError turns a failed FindByID into a not found error when there is no
+// such document, and into an internal error otherwise.
+func lookupError(err error, notFound, internal string) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return apperrors.NotFound(notFound)
+	}
+	return apperrors.Internal(internal, err)
+}
+
+// listParams parses the paging, sorting and filter parameters of a list
+// request. It records a validation error and returns false when they are
+// malformed.
+func listParams(c *gin.Context, spec query.Spec) (query.Params, bool) {
+	params, err := query.Parse(c.Request.URL.Query(), spec)
+	if err != nil {
+		c.Error(apperrors.Invalid(err))
+		return params, false
+	}
+	return params, true
+}
+
+// listError reports a failed List as a validation error when the client sent
+// a bad cursor, and as an internal error otherwise.
+func listError(err error, internal string) error {
+	if errors.Is(err, query.ErrInvalidCursor) {
+		return apperrors.Validation("cursor is not valid for this list and sort order")
+	}
+	return apperrors.Internal(internal, err)
+}
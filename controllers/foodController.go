@@ -2,89 +2,102 @@ package controller
 
 import (
 	"context"
-	"golang-restaurant-management/database"
+	"golang-restaurant-management/apperrors"
+	"golang-restaurant-management/audit"
 	"golang-restaurant-management/models"
-	"log"
+	"golang-restaurant-management/query"
+	"golang-restaurant-management/repository"
 	"math"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var foodCollection *mongo.Collection = database.OpenCollection(database.Client, "food")
-
-func GetFoods() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+type FoodController struct {
+	foods    repository.FoodRepository
+	menus    repository.MenuRepository
+	recorder *audit.Recorder
+}
 
-		// convert strings to integers with default values
-		recordPerPage, err := strconv.Atoi(c.Query("recordPerPage"))
-		if err != nil || recordPerPage < 1 {
-			recordPerPage = 10
-		}
+func NewFoodController(foods repository.FoodRepository, menus repository.MenuRepository, recorder *audit.Recorder) *FoodController {
+	return &FoodController{foods: foods, menus: menus, recorder: recorder}
+}
 
-		page, err := strconv.Atoi(c.Query("page"))
-		if err != nil || page < 1 {
-			page = 1
-		}
+// foodListSpec is what GET /foods can be filtered and sorted by.
+var foodListSpec = query.Spec{
+	Filters: []query.FilterSpec{
+		{Param: "menu_id", Field: "menu_id", Type: query.String},
+		{Param: "name", Field: "name", Type: query.String},
+		{Param: "price", Field: "price", Type: query.Number},
+		{Param: "created_at", Field: "created_at", Type: query.Time},
+	},
+	Sorts:        []string{"name", "price", "created_at", "updated_at"},
+	DefaultSort:  "created_at",
+	DefaultLimit: 10,
+}
 
-		startIndex := (page - 1) * recordPerPage
-		if s := c.Query("startIndex"); s != "" {
-			startIndex, err = strconv.Atoi(s)
-			if err != nil {
-				startIndex = (page - 1) * recordPerPage
-			}
-		}
+// @Summary List foods
+// @Tags foods
+// @Produce json
+// @Param limit query int false "Items per page"
+// @Param offset query int false "Items to skip"
+// @Param page query int false "Page number, from 1"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Comma-separated fields, - for descending"
+// @Success 200 {object} query.Page[models.Food]
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/foods [get]
+func (fc *FoodController) GetFoods() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
 
-		matchStage := bson.D{{Key: "$match", Value: bson.D{}}}
-		groupStage := bson.D{{Key: "$group", Value: bson.D{{Key: "_id", Value: bson.D{{Key: "_id", Value: "null"}}}, {Key: "totalCount", Value: bson.D{{Key: "$sum", Value: 1}}}, {Key: "data", Value: bson.D{{Key: "$push", Value: "$$ROOT"}}}}}}
-		projectStage := bson.D{
-			{Key: "$project", Value: bson.D{
-				{Key: "_id", Value: 0},
-				{Key: "totalCount", Value: 1},
-				{Key: "food_items", Value: bson.D{{Key: "$slice", Value: []interface{}{"$data", startIndex, recordPerPage}}}},
-			}},
+		params, ok := listParams(c, foodListSpec)
+		if !ok {
+			return
 		}
 
-		result, err := foodCollection.Aggregate(ctx, mongo.Pipeline{
-			matchStage, groupStage, projectStage,
-		})
-
-		defer cancel()
+		page, err := fc.foods.List(ctx, params)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching food items"})
-		}
-
-		var allFood []bson.M
-
-		if err = result.All(ctx, &allFood); err != nil {
-			log.Fatal(err)
+			c.Error(listError(err, "Error occurred while fetching food items"))
 			return
 		}
 
-		c.JSON(http.StatusOK, allFood[0])
+		c.JSON(http.StatusOK, page)
 	}
 }
 
-func GetFood() gin.HandlerFunc {
+// @Summary Get a food
+// @Tags foods
+// @Produce json
+// @Param food_id path string true "Food ID"
+// @Success 200 {object} models.Food
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/foods/{food_id} [get]
+func (fc *FoodController) GetFood() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		foodId := c.Param("food_id")
 
-		var food models.Food
-
-		err := foodCollection.FindOne(ctx, bson.M{"food_id": foodId}).Decode(&food)
+		food, err := fc.foods.FindByID(ctx, foodId)
 		defer cancel()
 
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching food item"})
+			c.Error(lookupError(err, "Food item was not found", "Error occurred while fetching food item"))
 			return
 		}
 
@@ -92,31 +105,44 @@ func GetFood() gin.HandlerFunc {
 	}
 }
 
-func CreateFood() gin.HandlerFunc {
+// @Summary Create a food
+// @Tags foods
+// @Accept json
+// @Produce json
+// @Param body body models.Food true "Food"
+// @Success 201 {object} models.Food
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/foods [post]
+func (fc *FoodController) CreateFood() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
 
-		var menu models.Menu
 		var food models.Food
 
-		if err := c.BindJSON(&food); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&food); err != nil {
+			c.Error(apperrors.Invalid(err))
 			return
 		}
 
 		var validate = validator.New()
 		validationErr := validate.Struct(food)
 		if validationErr != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			c.Error(apperrors.Invalid(validationErr))
 			return
 		}
 
-		err := menuCollection.FindOne(ctx, bson.M{"menu_id": food.Menu_id}).Decode(&menu)
+		_, err := fc.menus.FindByID(ctx, *food.Menu_id)
 
 		if err != nil {
 			msg := "Menu was not found"
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.Error(lookupError(err, msg, "Error occurred while fetching menu"))
 			return
 		}
 
@@ -127,16 +153,18 @@ func CreateFood() gin.HandlerFunc {
 		var num = toFixed(*food.Price, 2)
 		food.Price = &num
 
-		result, insertErr := foodCollection.InsertOne(ctx, food)
+		_, insertErr := fc.foods.Create(ctx, food)
 
 		if insertErr != nil {
 			msg := "Food item was not created"
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.Error(apperrors.Internal(msg, insertErr))
 			return
 		}
 
+		fc.recorder.Record(c, audit.EntityFood, food.Food_id, nil, food)
+
 		defer cancel()
-		c.JSON(http.StatusOK, result)
+		c.JSON(http.StatusCreated, food)
 	}
 }
 
@@ -149,71 +177,109 @@ func toFixed(num float64, precision int) float64 {
 	return float64(round(num*output)) / output
 }
 
-func UpdateFood() gin.HandlerFunc {
+// @Summary Update a food
+// @Tags foods
+// @Accept json
+// @Produce json
+// @Param food_id path string true "Food ID"
+// @Param body body models.Food true "Fields to change"
+// @Success 200 {object} models.Food
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/foods/{food_id} [patch]
+func (fc *FoodController) UpdateFood() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
-		var menu models.Menu
 		var food models.Food
 
 		foodId := c.Param("food_id")
 
-		if err := c.BindJSON(&food); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&food); err != nil {
+			c.Error(apperrors.Invalid(err))
 			return
 		}
 
 		var updateObj primitive.D
+		var fields []string
 
 		if food.Name != nil {
 			updateObj = append(updateObj, bson.E{Key: "name", Value: food.Name})
+			fields = append(fields, "Name")
 		}
 
 		if food.Price != nil {
-			updateObj = append(updateObj, bson.E{Key: "price", Value: food.Price})
+			var num = toFixed(*food.Price, 2)
+			food.Price = &num
+			updateObj = append(updateObj, bson.E{Key: "price", Value: num})
+			fields = append(fields, "Price")
 		}
 
 		if food.Food_image != nil {
 			updateObj = append(updateObj, bson.E{Key: "food_image", Value: food.Food_image})
+			fields = append(fields, "Food_image")
 		}
 
 		if food.Menu_id != nil {
-			err := menuCollection.FindOne(ctx, bson.M{"menu_id": food.Menu_id}).Decode(&menu)
-			defer cancel()
+			updateObj = append(updateObj, bson.E{Key: "menu_id", Value: food.Menu_id})
+			fields = append(fields, "Menu_id")
+		}
+
+		if len(updateObj) == 0 {
+			c.Error(apperrors.Invalid(apperrors.ErrNoChanges))
+			return
+		}
 
-			if err != nil {
-				msg := "Menu was not found"
-				c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		// only the fields that were sent are checked against the model's rules
+		if validationErr := validator.New().StructPartial(food, fields...); validationErr != nil {
+			c.Error(apperrors.Invalid(validationErr))
+			return
+		}
+
+		if food.Menu_id != nil {
+			if _, err := fc.menus.FindByID(ctx, *food.Menu_id); err != nil {
+				c.Error(lookupError(err, "Menu was not found", "Error occurred while fetching menu"))
 				return
 			}
-
-			updateObj = append(updateObj, bson.E{Key: "menu_id", Value: food.Menu_id})
 		}
 
 		food.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
 		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: food.Updated_at})
 
-		upsert := true
-		filter := bson.M{"food_id": foodId}
-
-		opt := options.UpdateOptions{
-			Upsert: &upsert,
+		before, err := fc.foods.FindByID(ctx, foodId)
+		if err != nil {
+			c.Error(lookupError(err, "Food item was not found", "Error occurred while fetching food item"))
+			return
 		}
 
-		result, err := foodCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{
-				{Key: "$set", Value: updateObj},
-			},
-			&opt,
-		)
+		if _, err := fc.foods.Update(ctx, foodId, updateObj); err != nil {
+			c.Error(apperrors.Internal("Food item update failed", err))
+			return
+		}
 
+		updated, err := fc.foods.FindByID(ctx, foodId)
 		if err != nil {
-			msg := "Food item update failed"
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.Error(apperrors.Internal("Error occurred while fetching food item", err))
+			return
 		}
-		c.JSON(http.StatusOK, result)
+
+		fc.recorder.Record(c, audit.EntityFood, foodId, before, updated)
+
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// snapshot turns the result of a Find into the state stored in the audit log,
+// nil when it could not be loaded.
+func snapshot(document interface{}, err error) interface{} {
+	if err != nil {
+		return nil
 	}
+	return document
 }
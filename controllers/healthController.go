@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"golang-restaurant-management/version"
+
+	"github.com/gin-gonic/gin"
+)
+
+const readinessTimeout = 2 * time.Second
+
+// HealthCheck probes one dependency the service needs to serve requests.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type DependencyStatus struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type ReadinessResponse struct {
+	Status       string             `json:"status"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+// HealthController serves the unauthenticated probes an orchestrator uses to
+// decide whether to restart the process or route traffic to it.
+type HealthController struct {
+	checks []HealthCheck
+}
+
+func NewHealthController(checks ...HealthCheck) *HealthController {
+	return &HealthController{checks: checks}
+}
+
+// Healthz reports that the process is up and serving HTTP. It checks no
+// dependencies, so a database outage does not get the process restarted.
+//
+// @Summary Liveness probe
+// @Tags system
+// @Produce json
+// @Success 200 {object} StatusResponse
+// @Router /healthz [get]
+func (hc *HealthController) Healthz() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, StatusResponse{Status: "ok"})
+	}
+}
+
+// Readyz runs every dependency check and answers 503 when any of them fails.
+//
+// @Summary Readiness probe
+// @Tags system
+// @Produce json
+// @Success 200 {object} ReadinessResponse
+// @Failure 503 {object} ReadinessResponse
+// @Router /readyz [get]
+func (hc *HealthController) Readyz() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+		defer cancel()
+
+		response := ReadinessResponse{Status: "ok", Dependencies: []DependencyStatus{}}
+
+		for _, check := range hc.checks {
+			start := time.Now()
+			err := check.Check(ctx)
+
+			dependency := DependencyStatus{
+				Name:      check.Name,
+				Status:    "ok",
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				dependency.Status = "unavailable"
+				dependency.Error = err.Error()
+				response.Status = "unavailable"
+			}
+
+			response.Dependencies = append(response.Dependencies, dependency)
+		}
+
+		status := http.StatusOK
+		if response.Status != "ok" {
+			status = http.StatusServiceUnavailable
+		}
+
+		c.JSON(status, response)
+	}
+}
+
+// Version returns the build the process is running.
+//
+// @Summary Build information
+// @Tags system
+// @Produce json
+// @Success 200 {object} version.Info
+// @Router /version [get]
+func (hc *HealthController) Version() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, version.Get())
+	}
+}
@@ -3,9 +3,11 @@ package controller
 import (
 	"context"
 	"fmt"
-	"golang-restaurant-management/database"
+	"golang-restaurant-management/apperrors"
+	"golang-restaurant-management/audit"
 	"golang-restaurant-management/models"
-	"log"
+	"golang-restaurant-management/query"
+	"golang-restaurant-management/repository"
 	"net/http"
 	"time"
 
@@ -13,67 +15,109 @@ import (
 	"github.com/go-playground/validator/v10"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type InvoiceViewFormat struct {
-	Invoice_id       string
-	Payment_method   string
-	Order_id         string
-	Payment_status   *string
-	Payment_due      interface{}
-	Table_number     interface{}
-	Payment_due_date time.Time
-	Order_details    interface{}
+	Invoice_id       string      `json:"invoice_id"`
+	Payment_method   string      `json:"payment_method"`
+	Order_id         string      `json:"order_id"`
+	Payment_status   *string     `json:"payment_status"`
+	Payment_due      interface{} `json:"payment_due"`
+	Table_number     interface{} `json:"table_number"`
+	Payment_due_date time.Time   `json:"payment_due_date"`
+	Order_details    interface{} `json:"order_details"`
 }
 
-var invoiceCollection *mongo.Collection = database.OpenCollection(database.Client, "invoice")
+type InvoiceController struct {
+	invoices   repository.InvoiceRepository
+	orders     repository.OrderRepository
+	orderItems repository.OrderItemRepository
+	recorder   *audit.Recorder
+}
 
-func GetInvoices() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
-		defer cancel()
+func NewInvoiceController(invoices repository.InvoiceRepository, orders repository.OrderRepository, orderItems repository.OrderItemRepository, recorder *audit.Recorder) *InvoiceController {
+	return &InvoiceController{invoices: invoices, orders: orders, orderItems: orderItems, recorder: recorder}
+}
 
-		result, err := invoiceCollection.Find(ctx, bson.M{})
+// invoiceListSpec is what GET /invoices can be filtered and sorted by.
+var invoiceListSpec = query.Spec{
+	Filters: []query.FilterSpec{
+		{Param: "order_id", Field: "order_id", Type: query.String},
+		{Param: "payment_status", Field: "payment_status", Type: query.String},
+		{Param: "payment_method", Field: "payment_method", Type: query.String},
+		{Param: "payment_due_date", Field: "payment_due_date", Type: query.Time},
+		{Param: "created_at", Field: "created_at", Type: query.Time},
+	},
+	Sorts:       []string{"payment_due_date", "payment_status", "created_at"},
+	DefaultSort: "-created_at",
+}
 
+// @Summary List invoices
+// @Tags invoices
+// @Produce json
+// @Param limit query int false "Items per page"
+// @Param offset query int false "Items to skip"
+// @Param page query int false "Page number, from 1"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Comma-separated fields, - for descending"
+// @Success 200 {object} query.Page[models.Invoice]
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/invoices [get]
+func (ic *InvoiceController) GetInvoices() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
 
-		if err != nil {
-			c.JSON(500, gin.H{"error": "Error occurred while fetching orders"})
+		params, ok := listParams(c, invoiceListSpec)
+		if !ok {
 			return
 		}
 
-		var allInvoices []bson.M
-		if err = result.All(ctx, &allInvoices); err != nil {
-			log.Fatal(err)
+		page, err := ic.invoices.List(ctx, params)
+		if err != nil {
+			c.Error(listError(err, "Error occurred while fetching invoices"))
 			return
 		}
 
-		c.JSON((http.StatusOK), allInvoices)
+		c.JSON(http.StatusOK, page)
 	}
 }
 
-func GetInvoice() gin.HandlerFunc {
+// @Summary Get a invoice
+// @Tags invoices
+// @Produce json
+// @Param invoice_id path string true "Invoice ID"
+// @Success 200 {object} InvoiceViewFormat
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/invoices/{invoice_id} [get]
+func (ic *InvoiceController) GetInvoice() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		invoiceID := c.Param("invoice_id")
 
-		var invoice models.Invoice
-
-		err := invoiceCollection.FindOne(ctx, bson.M{"invoice_id": invoiceID}).Decode(&invoice)
+		invoice, err := ic.invoices.FindByID(ctx, invoiceID)
 		defer cancel()
 
 		if err != nil {
-			c.JSON(500, gin.H{"error": "Error occurred while fetching invoice"})
+			c.Error(lookupError(err, "Invoice was not found", "Error occurred while fetching invoice"))
 			return
 		}
 
 		var invoiceView InvoiceViewFormat
 
-		allOrderItems, err := ItemsByOrder(invoice.Order_id)
+		allOrderItems, err := ic.orderItems.ItemsByOrder(ctx, invoice.Order_id)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching order items"})
+			c.Error(apperrors.Internal("Error occurred while fetching order items", err))
 			return
 		}
 
@@ -87,33 +131,47 @@ func GetInvoice() gin.HandlerFunc {
 
 		invoiceView.Invoice_id = invoice.Invoice_id
 		invoiceView.Payment_status = invoice.Payment_status
-		invoiceView.Payment_due = allOrderItems[0]["payment_due"]
-		invoiceView.Table_number = allOrderItems[0]["table_number"]
-		invoiceView.Order_details = allOrderItems[0]["order_items"]
+		if len(allOrderItems) > 0 {
+			invoiceView.Payment_due = allOrderItems[0]["total_amount"]
+			invoiceView.Table_number = allOrderItems[0]["table_number"]
+			invoiceView.Order_details = allOrderItems[0]["order_items"]
+		}
 
 		c.JSON(http.StatusOK, invoiceView)
 
 	}
 }
 
-func CreateInvoice() gin.HandlerFunc {
+// @Summary Create a invoice
+// @Tags invoices
+// @Accept json
+// @Produce json
+// @Param body body models.Invoice true "Invoice"
+// @Success 201 {object} models.Invoice
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/invoices [post]
+func (ic *InvoiceController) CreateInvoice() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
 
 		var invoice models.Invoice
 
-		if err := c.BindJSON(&invoice); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&invoice); err != nil {
+			c.Error(apperrors.Invalid(err))
 			return
 		}
 
-		var order models.Order
-
-		err := orderCollection.FindOne(ctx, bson.M{"order_id": invoice.Order_id}).Decode(&order)
+		_, err := ic.orders.FindByID(ctx, invoice.Order_id)
 		if err != nil {
 			msg := fmt.Sprintf("Order with ID %s not found", invoice.Order_id)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.Error(lookupError(err, msg, "Error occurred while fetching order"))
 			return
 		}
 
@@ -132,25 +190,42 @@ func CreateInvoice() gin.HandlerFunc {
 		validationErr := validate.Struct(invoice)
 
 		if validationErr != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			c.Error(apperrors.Invalid(validationErr))
 			return
 		}
 
-		result, insertErr := invoiceCollection.InsertOne(ctx, invoice)
+		_, insertErr := ic.invoices.Create(ctx, invoice)
 
 		if insertErr != nil {
 			msg := "Invoice was not created"
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.Error(apperrors.Internal(msg, insertErr))
 			return
 		}
 
+		ic.recorder.Record(c, audit.EntityInvoice, invoice.Invoice_id, nil, invoice)
+
 		defer cancel()
 
-		c.JSON(http.StatusOK, result)
+		c.JSON(http.StatusCreated, invoice)
 	}
 }
 
-func UpdateInvoice() gin.HandlerFunc {
+// @Summary Update a invoice
+// @Tags invoices
+// @Accept json
+// @Produce json
+// @Param invoice_id path string true "Invoice ID"
+// @Param body body models.Invoice true "Fields to change"
+// @Success 200 {object} models.Invoice
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/invoices/{invoice_id} [patch]
+func (ic *InvoiceController) UpdateInvoice() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
@@ -158,54 +233,57 @@ func UpdateInvoice() gin.HandlerFunc {
 		var invoice models.Invoice
 		invoiceID := c.Param("invoice_id")
 
-		if err := c.BindJSON(&invoice); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&invoice); err != nil {
+			c.Error(apperrors.Invalid(err))
 			return
 		}
 
-		filter := bson.M{"invoice_id": invoiceID}
-
 		var updateObj primitive.D
+		var fields []string
 
 		if invoice.Payment_method != nil {
 			updateObj = append(updateObj, bson.E{Key: "payment_method", Value: invoice.Payment_method})
+			fields = append(fields, "Payment_method")
 		}
 
 		if invoice.Payment_status != nil {
 			updateObj = append(updateObj, bson.E{Key: "payment_status", Value: invoice.Payment_status})
+			fields = append(fields, "Payment_status")
+		}
+
+		if len(updateObj) == 0 {
+			c.Error(apperrors.Invalid(apperrors.ErrNoChanges))
+			return
+		}
+
+		// only the fields that were sent are checked against the model's rules
+		if validationErr := validator.New().StructPartial(invoice, fields...); validationErr != nil {
+			c.Error(apperrors.Invalid(validationErr))
+			return
 		}
 
 		invoice.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: invoice.Updated_at})
 
-		upsert := true
-		opt := options.UpdateOptions{
-			Upsert: &upsert,
+		before, err := ic.invoices.FindByID(ctx, invoiceID)
+		if err != nil {
+			c.Error(lookupError(err, "Invoice was not found", "Error occurred while fetching invoice"))
+			return
 		}
 
-		status := "PENDING"
-		if invoice.Payment_status != nil {
-			invoice.Payment_status = &status
+		if _, err := ic.invoices.Update(ctx, invoiceID, updateObj); err != nil {
+			c.Error(apperrors.Internal("Invoice was not updated", err))
+			return
 		}
 
-		result, err := invoiceCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{
-				{Key: "$set", Value: updateObj},
-			},
-			&opt,
-		)
-
+		updated, err := ic.invoices.FindByID(ctx, invoiceID)
 		if err != nil {
-			msg := "Invoice was not updated"
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.Error(apperrors.Internal("Error occurred while fetching invoice", err))
 			return
 		}
 
-		defer cancel()
-
-		c.JSON(http.StatusOK, result)
+		ic.recorder.Record(c, audit.EntityInvoice, invoiceID, before, updated)
 
+		c.JSON(http.StatusOK, updated)
 	}
 }
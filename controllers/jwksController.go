@@ -0,0 +1,23 @@
+package controller
+
+import (
+	"golang-restaurant-management/helper"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetJWKS publishes the public keys other services need to verify our access
+// tokens without knowing any secret.
+//
+// @Summary Public keys for verifying access tokens
+// @Tags system
+// @Produce json
+// @Success 200 {object} JWKSResponse
+// @Router /.well-known/jwks.json [get]
+func GetJWKS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, JWKSResponse{Keys: helper.JSONWebKeySet()})
+	}
+}
@@ -2,9 +2,11 @@ package controller
 
 import (
 	"context"
-	"golang-restaurant-management/database"
+	"golang-restaurant-management/apperrors"
+	"golang-restaurant-management/audit"
 	"golang-restaurant-management/models"
-	"log"
+	"golang-restaurant-management/query"
+	"golang-restaurant-management/repository"
 	"net/http"
 	"time"
 
@@ -12,45 +14,87 @@ import (
 	"github.com/go-playground/validator/v10"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var menuCollection *mongo.Collection = database.OpenCollection(database.Client, "menu")
+type MenuController struct {
+	menus    repository.MenuRepository
+	recorder *audit.Recorder
+}
 
-func GetMenus() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+func NewMenuController(menus repository.MenuRepository, recorder *audit.Recorder) *MenuController {
+	return &MenuController{menus: menus, recorder: recorder}
+}
 
-		result, err := menuCollection.Find(context.TODO(), bson.M{})
+// menuListSpec is what GET /menus can be filtered and sorted by.
+var menuListSpec = query.Spec{
+	Filters: []query.FilterSpec{
+		{Param: "category", Field: "category", Type: query.String},
+		{Param: "start_date", Field: "start_date", Type: query.Time},
+		{Param: "end_date", Field: "end_date", Type: query.Time},
+		{Param: "created_at", Field: "created_at", Type: query.Time},
+	},
+	Sorts:       []string{"name", "category", "start_date", "end_date", "created_at"},
+	DefaultSort: "created_at",
+}
 
+// @Summary List menus
+// @Tags menus
+// @Produce json
+// @Param limit query int false "Items per page"
+// @Param offset query int false "Items to skip"
+// @Param page query int false "Page number, from 1"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Comma-separated fields, - for descending"
+// @Success 200 {object} query.Page[models.Menu]
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/menus [get]
+func (mc *MenuController) GetMenus() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching menus"})
+
+		params, ok := listParams(c, menuListSpec)
+		if !ok {
 			return
 		}
 
-		var allMenus []bson.M
-		if err = result.All(ctx, &allMenus); err != nil {
-			log.Fatal(err)
+		page, err := mc.menus.List(ctx, params)
+		if err != nil {
+			c.Error(listError(err, "Error occurred while fetching menus"))
+			return
 		}
 
-		c.JSON(http.StatusOK, allMenus)
+		c.JSON(http.StatusOK, page)
 	}
 }
 
-func GetMenu() gin.HandlerFunc {
+// @Summary Get a menu
+// @Tags menus
+// @Produce json
+// @Param menu_id path string true "Menu ID"
+// @Success 200 {object} models.Menu
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/menus/{menu_id} [get]
+func (mc *MenuController) GetMenu() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		menuId := c.Param("menu_id")
 
-		var menu models.Menu
-
-		err := foodCollection.FindOne(ctx, bson.M{"food_id": menuId}).Decode(&menu)
+		menu, err := mc.menus.FindByID(ctx, menuId)
 		defer cancel()
 
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching menu"})
+			c.Error(lookupError(err, "Menu was not found", "Error occurred while fetching menu"))
 			return
 		}
 
@@ -58,21 +102,35 @@ func GetMenu() gin.HandlerFunc {
 	}
 }
 
-func CreateMenu() gin.HandlerFunc {
+// @Summary Create a menu
+// @Tags menus
+// @Accept json
+// @Produce json
+// @Param body body models.Menu true "Menu"
+// @Success 201 {object} models.Menu
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/menus [post]
+func (mc *MenuController) CreateMenu() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var menu models.Menu
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
 
-		if err := c.BindJSON(&menu); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&menu); err != nil {
+			c.Error(apperrors.Invalid(err))
 			return
 		}
 
 		var validate = validator.New()
 		validationErr := validate.Struct(menu)
 		if validationErr != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			c.Error(apperrors.Invalid(validationErr))
 			return
 		}
 
@@ -81,16 +139,18 @@ func CreateMenu() gin.HandlerFunc {
 		menu.ID = primitive.NewObjectID()
 		menu.Menu_id = menu.ID.Hex()
 
-		result, insertErr := menuCollection.InsertOne(ctx, menu)
+		_, insertErr := mc.menus.Create(ctx, menu)
 
 		if insertErr != nil {
 			msg := "Menu item was not created"
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.Error(apperrors.Internal(msg, insertErr))
 			return
 		}
 
+		mc.recorder.Record(c, audit.EntityMenu, menu.Menu_id, nil, menu)
+
 		defer cancel()
-		c.JSON(http.StatusOK, result)
+		c.JSON(http.StatusCreated, menu)
 		defer cancel()
 	}
 }
@@ -99,67 +159,91 @@ func inTimeSpan(start, end, check time.Time) bool {
 	return start.After(check) && end.After(start)
 }
 
-func UpdateMenu() gin.HandlerFunc {
+// @Summary Update a menu
+// @Tags menus
+// @Accept json
+// @Produce json
+// @Param menu_id path string true "Menu ID"
+// @Param body body models.Menu true "Fields to change"
+// @Success 200 {object} models.Menu
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/menus/{menu_id} [patch]
+func (mc *MenuController) UpdateMenu() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
 
 		var menu models.Menu
 
-		if err := c.BindJSON(&menu); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&menu); err != nil {
+			c.Error(apperrors.Invalid(err))
 			return
 		}
 
 		menuId := c.Param("menu_id")
-		filter := bson.M{"menu_id": menuId}
 
 		var updateObj primitive.D
 
-		if menu.Start_date != nil && menu.End_date != nil {
+		// the dates can only be changed together, since each is checked
+		// against the other
+
+		if (menu.Start_date == nil) != (menu.End_date == nil) {
+			c.Error(apperrors.Validation("start_date and end_date must be updated together"))
+			return
+		}
+
+		if menu.Start_date != nil {
 			if !inTimeSpan(*menu.Start_date, *menu.End_date, time.Now()) {
 				msg := "kindly retype the start date and end date"
-				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
-				defer cancel()
+				c.Error(apperrors.Validation(msg))
 				return
 			}
 
 			updateObj = append(updateObj, bson.E{Key: "start_date", Value: menu.Start_date})
 			updateObj = append(updateObj, bson.E{Key: "end_date", Value: menu.End_date})
+		}
 
-			if menu.Name != "" {
-				updateObj = append(updateObj, bson.E{Key: "name", Value: menu.Name})
-			}
+		if menu.Name != "" {
+			updateObj = append(updateObj, bson.E{Key: "name", Value: menu.Name})
+		}
 
-			if menu.Category != "" {
-				updateObj = append(updateObj, bson.E{Key: "category", Value: menu.Category})
-			}
+		if menu.Category != "" {
+			updateObj = append(updateObj, bson.E{Key: "category", Value: menu.Category})
+		}
 
-			menu.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-			updateObj = append(updateObj, bson.E{Key: "updated_at", Value: menu.Updated_at})
+		if len(updateObj) == 0 {
+			c.Error(apperrors.Invalid(apperrors.ErrNoChanges))
+			return
+		}
 
-			upsert := true
+		menu.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: menu.Updated_at})
 
-			opt := options.UpdateOptions{
-				Upsert: &upsert,
-			}
+		before, err := mc.menus.FindByID(ctx, menuId)
+		if err != nil {
+			c.Error(lookupError(err, "Menu was not found", "Error occurred while fetching menu"))
+			return
+		}
 
-			result, err := menuCollection.UpdateOne(
-				ctx,
-				filter,
-				bson.D{
-					{Key: "$set", Value: updateObj},
-				},
-				&opt,
-			)
-
-			if err != nil {
-				msg := "Menu updated failed"
-				c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
-			}
+		if _, err := mc.menus.Update(ctx, menuId, updateObj); err != nil {
+			c.Error(apperrors.Internal("Menu updated failed", err))
+			return
+		}
 
-			defer cancel()
-			c.JSON(http.StatusOK, result)
+		updated, err := mc.menus.FindByID(ctx, menuId)
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while fetching menu", err))
+			return
 		}
+
+		mc.recorder.Record(c, audit.EntityMenu, menuId, before, updated)
+
+		c.JSON(http.StatusOK, updated)
 	}
 }
@@ -0,0 +1,398 @@
+package controller
+
+import (
+	"context"
+	"golang-restaurant-management/apperrors"
+	"golang-restaurant-management/audit"
+	"golang-restaurant-management/helper"
+	"golang-restaurant-management/models"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	recoveryCodeCount = 10
+	mfaFailureLimit   = 5
+)
+
+// @Summary Start enrolling in two-factor authentication
+// @Tags users
+// @Produce json
+// @Success 200 {object} MfaEnrollmentResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 409 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/users/mfa/enroll [post]
+func (uc *UserController) EnrollMfa() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		user, err := uc.repos.Users.FindByID(ctx, c.GetString("uid"))
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while fetching user", err))
+			return
+		}
+
+		if mfaEnabled(user) {
+			c.Error(apperrors.Conflict("Two-factor authentication is already enabled"))
+			return
+		}
+
+		secret, err := helper.GenerateTOTPSecret()
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to generate secret", err))
+			return
+		}
+
+		// the secret only becomes active once a code generated from it is confirmed
+
+		_, err = uc.repos.Users.Update(ctx, user.User_id, bson.D{{Key: "mfa_pending_secret", Value: secret}})
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to store secret", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, MfaEnrollmentResponse{
+			Secret:           secret,
+			Provisioning_uri: helper.TOTPProvisioningURI(secret, *user.Email),
+		})
+	}
+}
+
+// @Summary Confirm two-factor enrollment
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body MfaCodeRequest true "Code from the authenticator app"
+// @Success 200 {object} RecoveryCodesResponse
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/users/mfa/confirm [post]
+func (uc *UserController) ConfirmMfa() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		var request MfaCodeRequest
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.Invalid(err))
+			return
+		}
+
+		var validate = validator.New()
+		if validationErr := validate.Struct(request); validationErr != nil {
+			c.Error(apperrors.Invalid(validationErr))
+			return
+		}
+
+		user, err := uc.repos.Users.FindByID(ctx, c.GetString("uid"))
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while fetching user", err))
+			return
+		}
+
+		if user.Mfa_pending_secret == nil {
+			c.Error(apperrors.BadRequest("Start the enrollment first"))
+			return
+		}
+
+		step, ok := helper.ValidateTOTP(*user.Mfa_pending_secret, request.Code, 0)
+		if !ok {
+			c.Error(apperrors.BadRequest("Invalid code"))
+			return
+		}
+
+		recoveryCodes, err := helper.GenerateRecoveryCodes(recoveryCodeCount)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to generate recovery codes", err))
+			return
+		}
+
+		hashedCodes := make([]string, 0, len(recoveryCodes))
+		for _, code := range recoveryCodes {
+			hashedCodes = append(hashedCodes, helper.HashToken(code))
+		}
+
+		updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		_, err = uc.repos.Users.Update(ctx, user.User_id, bson.D{
+			{Key: "mfa_enabled", Value: true},
+			{Key: "mfa_secret", Value: *user.Mfa_pending_secret},
+			{Key: "mfa_pending_secret", Value: nil},
+			{Key: "mfa_recovery_codes", Value: hashedCodes},
+			{Key: "mfa_last_step", Value: step},
+			{Key: "updated_at", Value: updatedAt},
+		})
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to enable two-factor authentication", err))
+			return
+		}
+
+		uc.recorder.Record(c, audit.EntityUser, user.User_id, newUserAuditView(user), uc.userSnapshot(ctx, user.User_id))
+
+		// recovery codes are only ever shown here; the database keeps their hashes
+		c.JSON(http.StatusOK, RecoveryCodesResponse{
+			Message:        "Two-factor authentication enabled",
+			Recovery_codes: recoveryCodes,
+		})
+	}
+}
+
+// @Summary Disable two-factor authentication
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body DisableMfaRequest true "Current password and a code or recovery code"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 429 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/users/mfa/disable [post]
+func (uc *UserController) DisableMfa() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		var request DisableMfaRequest
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.Invalid(err))
+			return
+		}
+
+		var validate = validator.New()
+		if validationErr := validate.Struct(request); validationErr != nil {
+			c.Error(apperrors.Invalid(validationErr))
+			return
+		}
+
+		user, err := uc.repos.Users.FindByID(ctx, c.GetString("uid"))
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while fetching user", err))
+			return
+		}
+
+		if !mfaEnabled(user) || user.Mfa_secret == nil {
+			c.Error(apperrors.BadRequest("Two-factor authentication is not enabled"))
+			return
+		}
+
+		if userRole(user) == models.RoleManager {
+			c.Error(apperrors.Forbidden("Two-factor authentication is required for managers"))
+			return
+		}
+
+		// wrong codes count towards the same lock as the two-factor login
+		attemptKey := "mfa:" + user.User_id
+
+		lockRemaining, err := helper.LoginLockRemaining(ctx, uc.repos.LoginAttempts, attemptKey)
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while checking login attempts", err))
+			return
+		}
+
+		if lockRemaining > 0 {
+			rejectLockedLogin(c, lockRemaining)
+			return
+		}
+
+		passwordIsValid, msg := VerifyPassword(request.Password, *user.Password)
+		if !passwordIsValid {
+			c.Error(apperrors.BadRequest(msg))
+			return
+		}
+
+		var verified bool
+
+		if request.Code != "" {
+			verified, err = uc.useTOTPCode(ctx, user, request.Code)
+		} else {
+			verified, err = uc.useRecoveryCode(ctx, user, request.Recovery_code)
+		}
+
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while verifying code", err))
+			return
+		}
+
+		if !verified {
+			if err := helper.RecordLoginFailure(ctx, uc.repos.LoginAttempts, attemptKey, mfaFailureLimit); err != nil {
+				slog.ErrorContext(c.Request.Context(), "Error recording login failure", "error", err)
+			}
+			c.Error(apperrors.Unauthorized("Invalid code"))
+			return
+		}
+
+		if err := helper.ResetLoginAttempts(ctx, uc.repos.LoginAttempts, attemptKey); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Error resetting login attempts", "error", err)
+		}
+
+		updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		_, err = uc.repos.Users.Update(ctx, user.User_id, bson.D{
+			{Key: "mfa_enabled", Value: false},
+			{Key: "mfa_secret", Value: nil},
+			{Key: "mfa_pending_secret", Value: nil},
+			{Key: "mfa_recovery_codes", Value: nil},
+			{Key: "mfa_last_step", Value: int64(0)},
+			{Key: "updated_at", Value: updatedAt},
+		})
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to disable two-factor authentication", err))
+			return
+		}
+
+		uc.recorder.Record(c, audit.EntityUser, user.User_id, newUserAuditView(user), uc.userSnapshot(ctx, user.User_id))
+
+		c.JSON(http.StatusOK, MessageResponse{Message: "Two-factor authentication disabled"})
+	}
+}
+
+// LoginMfa exchanges the "mfa pending" token from Login() plus a TOTP or
+// recovery code for the normal token pair.
+//
+// @Summary Finish a two-factor login
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body LoginMfaRequest true "MFA token from login and a code or recovery code"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 429 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Router /api/v1/users/login/mfa [post]
+func (uc *UserController) LoginMfa() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		var request LoginMfaRequest
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.Invalid(err))
+			return
+		}
+
+		var validate = validator.New()
+		if validationErr := validate.Struct(request); validationErr != nil {
+			c.Error(apperrors.Invalid(validationErr))
+			return
+		}
+
+		claims, msg := helper.ValidateToken(request.Mfa_token)
+		if msg != "" {
+			c.Error(apperrors.Unauthorized(msg))
+			return
+		}
+
+		if claims.TokenType != "mfa_pending" {
+			c.Error(apperrors.Unauthorized("Invalid token type, mfa token required"))
+			return
+		}
+
+		attemptKey := "mfa:" + claims.Uid
+
+		lockRemaining, err := helper.LoginLockRemaining(ctx, uc.repos.LoginAttempts, attemptKey)
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while checking login attempts", err))
+			return
+		}
+
+		if lockRemaining > 0 {
+			rejectLockedLogin(c, lockRemaining)
+			return
+		}
+
+		user, err := uc.repos.Users.FindByID(ctx, claims.Uid)
+		if err != nil {
+			c.Error(apperrors.Unauthorized("Invalid mfa token"))
+			return
+		}
+
+		if !isActive(user) {
+			c.Error(apperrors.Forbidden("Account has been deactivated"))
+			return
+		}
+
+		if !mfaEnabled(user) || user.Mfa_secret == nil {
+			c.Error(apperrors.BadRequest("Two-factor authentication is not enabled"))
+			return
+		}
+
+		var verified bool
+
+		if request.Code != "" {
+			verified, err = uc.useTOTPCode(ctx, user, request.Code)
+		} else {
+			verified, err = uc.useRecoveryCode(ctx, user, request.Recovery_code)
+		}
+
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while verifying code", err))
+			return
+		}
+
+		if !verified {
+			if err := helper.RecordLoginFailure(ctx, uc.repos.LoginAttempts, attemptKey, mfaFailureLimit); err != nil {
+				slog.ErrorContext(c.Request.Context(), "Error recording login failure", "error", err)
+			}
+			c.Error(apperrors.Unauthorized("Invalid code"))
+			return
+		}
+
+		if err := helper.ResetLoginAttempts(ctx, uc.repos.LoginAttempts, attemptKey); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Error resetting login attempts", "error", err)
+		}
+
+		token, refreshToken, err := uc.startSession(c, user)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to generate tokens", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, TokenResponse{
+			User_id:       user.User_id,
+			Token:         token,
+			Refresh_token: refreshToken,
+		})
+	}
+}
+
+// useTOTPCode validates the code and records its time step, so the same code
+// cannot be used twice.
+func (uc *UserController) useTOTPCode(ctx context.Context, user models.User, code string) (bool, error) {
+	step, ok := helper.ValidateTOTP(*user.Mfa_secret, code, user.Mfa_last_step)
+	if !ok {
+		return false, nil
+	}
+
+	return uc.repos.Users.UseTOTPStep(ctx, user.User_id, step)
+}
+
+// useRecoveryCode removes the matching recovery code from the user, so each
+// one works only once.
+func (uc *UserController) useRecoveryCode(ctx context.Context, user models.User, code string) (bool, error) {
+	hashedCode := helper.HashToken(strings.ToLower(strings.TrimSpace(code)))
+
+	return uc.repos.Users.UseRecoveryCode(ctx, user.User_id, hashedCode)
+}
+
+func mfaEnabled(user models.User) bool {
+	return user.Mfa_enabled != nil && *user.Mfa_enabled
+}
@@ -2,9 +2,11 @@ package controller
 
 import (
 	"context"
-	"golang-restaurant-management/database"
+	"golang-restaurant-management/apperrors"
+	"golang-restaurant-management/audit"
 	"golang-restaurant-management/models"
-	"log"
+	"golang-restaurant-management/query"
+	"golang-restaurant-management/repository"
 	"net/http"
 	"time"
 
@@ -12,53 +14,87 @@ import (
 	"github.com/go-playground/validator/v10"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var orderCollection *mongo.Collection = database.OpenCollection(database.Client, "order")
+type OrderController struct {
+	orders   repository.OrderRepository
+	tables   repository.TableRepository
+	recorder *audit.Recorder
+}
 
-func GetOrders() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+func NewOrderController(orders repository.OrderRepository, tables repository.TableRepository, recorder *audit.Recorder) *OrderController {
+	return &OrderController{orders: orders, tables: tables, recorder: recorder}
+}
 
-		result, err := orderCollection.Find(ctx, bson.D{})
+// orderListSpec is what GET /orders can be filtered and sorted by.
+var orderListSpec = query.Spec{
+	Filters: []query.FilterSpec{
+		{Param: "table_id", Field: "table_id", Type: query.String},
+		{Param: "order_date", Field: "order_date", Type: query.Time},
+		{Param: "created_at", Field: "created_at", Type: query.Time},
+	},
+	Sorts:       []string{"order_date", "created_at", "updated_at"},
+	DefaultSort: "-order_date",
+}
 
+// @Summary List orders
+// @Tags orders
+// @Produce json
+// @Param limit query int false "Items per page"
+// @Param offset query int false "Items to skip"
+// @Param page query int false "Page number, from 1"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Comma-separated fields, - for descending"
+// @Success 200 {object} query.Page[models.Order]
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/orders [get]
+func (oc *OrderController) GetOrders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
 
-		if err != nil {
-			c.JSON(500, gin.H{"error": "Error occurred while fetching orders"})
-			return
-		}
-
-		var allOrders []bson.M
-		if err = result.All(ctx, &allOrders); err != nil {
-			log.Fatal(err)
+		params, ok := listParams(c, orderListSpec)
+		if !ok {
 			return
 		}
 
-		if len(allOrders) == 0 {
-			c.JSON(404, gin.H{"message": "No orders found"})
+		page, err := oc.orders.List(ctx, params)
+		if err != nil {
+			c.Error(listError(err, "Error occurred while fetching orders"))
 			return
 		}
 
-		c.JSON(200, allOrders)
-
+		c.JSON(http.StatusOK, page)
 	}
 }
 
-func GetOrder() gin.HandlerFunc {
+// @Summary Get a order
+// @Tags orders
+// @Produce json
+// @Param order_id path string true "Order ID"
+// @Success 200 {object} models.Order
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/orders/{order_id} [get]
+func (oc *OrderController) GetOrder() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		orderID := c.Param("order_id")
 
-		var order models.Order
-
-		err := orderCollection.FindOne(ctx, bson.M{"order_id": orderID}).Decode(&order)
+		order, err := oc.orders.FindByID(ctx, orderID)
 		defer cancel()
 
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching order"})
+			c.Error(lookupError(err, "Order was not found", "Error occurred while fetching order"))
 			return
 		}
 
@@ -66,16 +102,29 @@ func GetOrder() gin.HandlerFunc {
 	}
 }
 
-func CreateOrder() gin.HandlerFunc {
+// @Summary Create a order
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param body body models.Order true "Order"
+// @Success 201 {object} models.Order
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/orders [post]
+func (oc *OrderController) CreateOrder() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
 
-		var table models.Table
 		var order models.Order
 
-		if err := c.BindJSON(&order); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&order); err != nil {
+			c.Error(apperrors.Invalid(err))
 			return
 		}
 
@@ -83,18 +132,18 @@ func CreateOrder() gin.HandlerFunc {
 		validationErr := validate.Struct(order)
 
 		if validationErr != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			c.Error(apperrors.Invalid(validationErr))
 			return
 		}
 
 		if order.Table_id != nil {
-			err := tableCollection.FindOne(ctx, bson.M{"table_id": order.Table_id}).Decode(&table)
+			_, err := oc.tables.FindByID(ctx, *order.Table_id)
 
 			defer cancel()
 
 			if err != nil {
 				msg := "Table was not found"
-				c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+				c.Error(lookupError(err, msg, "Error occurred while fetching table"))
 				return
 			}
 		}
@@ -105,93 +154,96 @@ func CreateOrder() gin.HandlerFunc {
 		order.ID = primitive.NewObjectID()
 		order.Order_id = order.ID.Hex()
 
-		result, insertErr := orderCollection.InsertOne(ctx, order)
+		_, insertErr := oc.orders.Create(ctx, order)
 		if insertErr != nil {
 			msg := "Order was not created"
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.Error(apperrors.Internal(msg, insertErr))
 			return
 		}
 
+		oc.recorder.Record(c, audit.EntityOrder, order.Order_id, nil, order)
+
 		defer cancel()
-		c.JSON(http.StatusOK, result)
+		c.JSON(http.StatusCreated, order)
 	}
 }
 
-func UpdateOrder() gin.HandlerFunc {
+// @Summary Update a order
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param order_id path string true "Order ID"
+// @Param body body models.Order true "Fields to change"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/orders/{order_id} [patch]
+func (oc *OrderController) UpdateOrder() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
 
-		var table models.Table
 		var order models.Order
 
 		var updateObj primitive.D
 
 		orderID := c.Param("order_id")
 
-		if err := c.BindJSON(&order); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&order); err != nil {
+			c.Error(apperrors.Invalid(err))
 			return
 		}
 
-		if order.Table_id != nil {
-			err := tableCollection.FindOne(ctx, bson.M{"table_id": order.Table_id}).Decode(&table)
-			if err != nil {
-				msg := "Table was not found"
-				c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
-				return
-			}
+		var fields []string
 
+		if order.Table_id != nil {
 			updateObj = append(updateObj, bson.E{Key: "table_id", Value: order.Table_id})
+			fields = append(fields, "Table_id")
 		}
 
-		order.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: order.Updated_at})
-
-		upsert := true
+		if len(updateObj) == 0 {
+			c.Error(apperrors.Invalid(apperrors.ErrNoChanges))
+			return
+		}
 
-		filter := bson.M{"order_id": orderID}
+		// only the fields that were sent are checked against the model's rules
+		if validationErr := validator.New().StructPartial(order, fields...); validationErr != nil {
+			c.Error(apperrors.Invalid(validationErr))
+			return
+		}
 
-		opt := options.UpdateOptions{
-			Upsert: &upsert,
+		if _, err := oc.tables.FindByID(ctx, *order.Table_id); err != nil {
+			c.Error(lookupError(err, "Table was not found", "Error occurred while fetching table"))
+			return
 		}
 
-		result, err := orderCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{
-				{Key: "$set", Value: updateObj},
-			},
-			&opt,
-		)
+		order.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: order.Updated_at})
 
+		before, err := oc.orders.FindByID(ctx, orderID)
 		if err != nil {
-			msg := "Order was not updated"
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.Error(lookupError(err, "Order was not found", "Error occurred while fetching order"))
 			return
 		}
 
-		defer cancel()
-
-		c.JSON(http.StatusOK, result)
-	}
-}
-
-func OrderItemOrderCreator(order models.Order) string {
-
-	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-
-	order.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-
-	order.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-
-	order.ID = primitive.NewObjectID()
-
-	order.Order_id = order.ID.Hex()
+		if _, err := oc.orders.Update(ctx, orderID, updateObj); err != nil {
+			c.Error(apperrors.Internal("Order was not updated", err))
+			return
+		}
 
-	orderCollection.InsertOne(ctx, order)
+		updated, err := oc.orders.FindByID(ctx, orderID)
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while fetching order", err))
+			return
+		}
 
-	defer cancel()
+		oc.recorder.Record(c, audit.EntityOrder, orderID, before, updated)
 
-	return order.Order_id
+		c.JSON(http.StatusOK, updated)
+	}
 }
@@ -2,9 +2,11 @@ package controller
 
 import (
 	"context"
-	"golang-restaurant-management/database"
+	"golang-restaurant-management/apperrors"
+	"golang-restaurant-management/audit"
 	"golang-restaurant-management/models"
-	"log"
+	"golang-restaurant-management/query"
+	"golang-restaurant-management/repository"
 	"net/http"
 	"time"
 
@@ -12,182 +14,173 @@ import (
 	"github.com/go-playground/validator/v10"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// OrderItemsSummary is an order's items joined with their food and table,
+// with the order total.
+type OrderItemsSummary struct {
+	Total_amount float64         `json:"total_amount"`
+	Total_count  int             `json:"total_count"`
+	Table_number *int            `json:"table_number"`
+	Order_items  []OrderItemLine `json:"order_items"`
+}
+
+// OrderItemLine is one item of an OrderItemsSummary.
+type OrderItemLine struct {
+	Order_id     string   `json:"order_id"`
+	Table_id     string   `json:"table_id"`
+	Table_number *int     `json:"table_number"`
+	Food_name    *string  `json:"food_name"`
+	Food_image   *string  `json:"food_image"`
+	Price        *float64 `json:"price"`
+	Quantity     *int     `json:"quantity"`
+	Amount       float64  `json:"amount"`
+}
+
 type OrderItemPack struct {
 	Table_id    *string
 	Order_items []models.OrderItem
 }
 
-var orderItemCollection *mongo.Collection = database.OpenCollection(database.Client, "order_items")
+type OrderItemController struct {
+	orderItems repository.OrderItemRepository
+	orders     repository.OrderRepository
+	recorder   *audit.Recorder
+}
 
-func GetOrderItems() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+func NewOrderItemController(orderItems repository.OrderItemRepository, orders repository.OrderRepository, recorder *audit.Recorder) *OrderItemController {
+	return &OrderItemController{orderItems: orderItems, orders: orders, recorder: recorder}
+}
 
-		result, err := orderItemCollection.Find(ctx, bson.M{})
+// orderItemListSpec is what GET /order-items can be filtered and sorted by.
+var orderItemListSpec = query.Spec{
+	Filters: []query.FilterSpec{
+		{Param: "order_id", Field: "order_id", Type: query.String},
+		{Param: "food_id", Field: "food_id", Type: query.String},
+		{Param: "quantity", Field: "quantity", Type: query.Number},
+		{Param: "created_at", Field: "created_at", Type: query.Time},
+	},
+	Sorts:       []string{"quantity", "unit_price", "created_at"},
+	DefaultSort: "created_at",
+}
 
+// @Summary List order items
+// @Tags order-items
+// @Produce json
+// @Param limit query int false "Items per page"
+// @Param offset query int false "Items to skip"
+// @Param page query int false "Page number, from 1"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Comma-separated fields, - for descending"
+// @Success 200 {object} query.Page[models.OrderItem]
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/order-items [get]
+func (oc *OrderItemController) GetOrderItems() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
 
-		if err != nil {
-			c.JSON(500, gin.H{"error": "Error occurred while fetching order items"})
+		params, ok := listParams(c, orderItemListSpec)
+		if !ok {
 			return
 		}
 
-		var allOrderItems []bson.M
-		if err = result.All(ctx, &allOrderItems); err != nil {
-			log.Fatal(err)
+		page, err := oc.orderItems.List(ctx, params)
+		if err != nil {
+			c.Error(listError(err, "Error occurred while fetching order items"))
 			return
 		}
 
-		c.JSON((http.StatusOK), allOrderItems)
-
+		c.JSON(http.StatusOK, page)
 	}
 }
 
-func GetOrderItemsByOrder() gin.HandlerFunc {
+// @Summary List the items of an order
+// @Tags order-items
+// @Produce json
+// @Param order_id path string true "Order ID"
+// @Success 200 {object} query.Page[OrderItemsSummary]
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/orders/{order_id}/order-items [get]
+func (oc *OrderItemController) GetOrderItemsByOrder() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
 		orderId := c.Param("order_id")
 
-		allOrderItems, err := ItemsByOrder(orderId)
+		if _, err := oc.orders.FindByID(ctx, orderId); err != nil {
+			c.Error(lookupError(err, "Order was not found", "Error occurred while fetching order"))
+			return
+		}
+
+		allOrderItems, err := oc.orderItems.ItemsByOrder(ctx, orderId)
 
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching order items"})
+			c.Error(apperrors.Internal("Error occurred while fetching order items", err))
 			return
 		}
 
-		c.JSON(http.StatusOK, allOrderItems)
+		summaries, err := newOrderItemsSummaries(allOrderItems)
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while reading order items", err))
+			return
+		}
 
+		c.JSON(http.StatusOK, query.Whole(summaries))
 	}
 }
 
-func ItemsByOrder(id string) (OrderItems []primitive.M, err error) {
-	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-
-	matchStage := bson.D{{Key: "$match", Value: bson.D{{Key: "order_id", Value: id}}}}
-
-	lookupStage := bson.D{
-		{Key: "$lookup", Value: bson.D{
-			{Key: "from", Value: "food"},
-			{Key: "localField", Value: "food_id"},
-			{Key: "foreignField", Value: "food_id"},
-			{Key: "as", Value: "food"},
-		}},
-	}
-	unwindStage := bson.D{
-		{Key: "$unwind", Value: bson.D{
-			{Key: "path", Value: "$food"},
-			{Key: "preserveNullAndEmptyArrays", Value: true},
-		}},
-	}
-
-	lookupOrderStage := bson.D{
-		{Key: "$lookup", Value: bson.D{
-			{Key: "from", Value: "order"},
-			{Key: "localField", Value: "order_id"},
-			{Key: "foreignField", Value: "order_id"},
-			{Key: "as", Value: "order"},
-		}},
-	}
-	unwindOrderStage := bson.D{
-		{Key: "$unwind", Value: bson.D{
-			{Key: "path", Value: "$order"},
-			{Key: "preserveNullAndEmptyArrays", Value: true},
-		}},
-	}
-
-	lookupTableStage := bson.D{
-		{Key: "$lookup", Value: bson.D{
-			{Key: "from", Value: "table"},
-			{Key: "localField", Value: "order.table_id"},
-			{Key: "foreignField", Value: "table_id"},
-			{Key: "as", Value: "table"},
-		}},
-	}
-	unwindTableStage := bson.D{
-		{Key: "$unwind", Value: bson.D{
-			{Key: "path", Value: "$table"},
-			{Key: "preserveNullAndEmptyArrays", Value: true},
-		}},
-	}
-
-	projectStage := bson.D{
-		{
-			Key: "$project", Value: bson.D{
-				{Key: "_id", Value: 0},
-				{Key: "amount", Value: bson.D{{Key: "$multiply", Value: bson.A{"$unit_price", "$quantity"}}}},
-				{Key: "total_count", Value: 1},
-				{Key: "food_name", Value: "$food.name"},
-				{Key: "food_image", Value: "$food.food_image"},
-				{Key: "table_number", Value: "$table.table_number"},
-				{Key: "table_id", Value: "$table.table_id"},
-				{Key: "order_id", Value: "$order.order_id"},
-				{Key: "price", Value: "$food.price"},
-				{Key: "quantity", Value: 1},
-			}}}
-
-	groupStage := bson.D{
-		{Key: "$group", Value: bson.D{
-			{Key: "_id", Value: bson.D{
-				{Key: "order_id", Value: "$order_id"},
-				{Key: "table_id", Value: "$table_id"},
-				{Key: "table_number", Value: "$table_number"},
-			}},
-			{Key: "total_amount", Value: bson.D{{Key: "$sum", Value: "$amount"}}},
-			{Key: "total_count", Value: bson.D{{Key: "$sum", Value: 1}}},
-			{Key: "order_items", Value: bson.D{{Key: "$push", Value: "$$ROOT"}}},
-		}},
-	}
-
-	projectStage2 := bson.D{
-		{Key: "$project", Value: bson.D{
-			{Key: "_id", Value: 0},
-			{Key: "total_amount", Value: 1},
-			{Key: "total_count", Value: 1},
-			{Key: "table_number", Value: "$_id.table_number"},
-			{Key: "order_items", Value: 1},
-		}}}
-
-	result, err := orderItemCollection.Aggregate(ctx, mongo.Pipeline{
-		matchStage,
-		lookupStage,
-		unwindStage,
-		lookupOrderStage,
-		unwindOrderStage,
-		lookupTableStage,
-		unwindTableStage,
-		projectStage,
-		groupStage,
-		projectStage2,
-	})
-
-	if err != nil {
-		panic(err)
-	}
+// newOrderItemsSummaries decodes the documents ItemsByOrder returns.
+func newOrderItemsSummaries(documents []primitive.M) ([]OrderItemsSummary, error) {
+	summaries := make([]OrderItemsSummary, 0, len(documents))
+	for _, document := range documents {
+		data, err := bson.Marshal(document)
+		if err != nil {
+			return nil, err
+		}
 
-	if err = result.All(ctx, &OrderItems); err != nil {
-		panic(err)
+		var summary OrderItemsSummary
+		if err := bson.Unmarshal(data, &summary); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
 	}
-
-	defer cancel()
-	return OrderItems, err
-
+	return summaries, nil
 }
 
-func GetOrderItem() gin.HandlerFunc {
+// @Summary Get an order item
+// @Tags order-items
+// @Produce json
+// @Param order_item_id path string true "Order-item ID"
+// @Success 200 {object} models.OrderItem
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/order-items/{order_item_id} [get]
+func (oc *OrderItemController) GetOrderItem() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		orderItemId := c.Param("order_item_id")
 
-		var orderItem models.OrderItem
-
-		err := orderItemCollection.FindOne(ctx, bson.M{"order_item_id": orderItemId}).Decode(&orderItem)
+		orderItem, err := oc.orderItems.FindByID(ctx, orderItemId)
 		defer cancel()
 
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching order item"})
+			c.Error(lookupError(err, "Order item was not found", "Error occurred while fetching order item"))
 			return
 		}
 
@@ -195,7 +188,21 @@ func GetOrderItem() gin.HandlerFunc {
 	}
 }
 
-func CreateOrderItem() gin.HandlerFunc {
+// @Summary Create an order with its items
+// @Tags order-items
+// @Accept json
+// @Produce json
+// @Param body body OrderItemPack true "Order-item"
+// @Success 201 {object} []models.OrderItem
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/order-items [post]
+func (oc *OrderItemController) CreateOrderItem() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
@@ -203,28 +210,35 @@ func CreateOrderItem() gin.HandlerFunc {
 		var orderItemPack OrderItemPack
 		var order models.Order
 
-		if err := c.BindJSON(&orderItemPack); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		if err := c.ShouldBindJSON(&orderItemPack); err != nil {
+			c.Error(apperrors.Invalid(err))
 			return
 		}
 
+		// validate every item before anything is written, so a bad item does
+		// not leave an order behind; the order id is only known afterwards
+
+		var validate = validator.New()
+		for _, orderItem := range orderItemPack.Order_items {
+			if validationErr := validate.StructExcept(orderItem, "Order_id"); validationErr != nil {
+				c.Error(apperrors.Invalid(validationErr))
+				return
+			}
+		}
+
 		order.Order_date, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
-		orderItemsToBeInserted := []interface{}{}
+		orderItemsToBeInserted := []models.OrderItem{}
 		order.Table_id = orderItemPack.Table_id
-		order_id := OrderItemOrderCreator(order)
+
+		order_id, err := oc.OrderItemOrderCreator(ctx, order)
+		if err != nil {
+			c.Error(apperrors.Internal("Order was not created", err))
+			return
+		}
 
 		for _, orderItem := range orderItemPack.Order_items {
 			orderItem.Order_id = order_id
-
-			var validate = validator.New()
-			validationErr := validate.Struct(orderItem)
-
-			if validationErr != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
-				return
-			}
-
 			orderItem.ID = primitive.NewObjectID()
 			orderItem.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 			orderItem.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
@@ -235,17 +249,36 @@ func CreateOrderItem() gin.HandlerFunc {
 			orderItemsToBeInserted = append(orderItemsToBeInserted, orderItem)
 		}
 
-		insertedOrderItems, err := orderItemCollection.InsertMany(ctx, orderItemsToBeInserted)
+		if _, err := oc.orderItems.CreateMany(ctx, orderItemsToBeInserted); err != nil {
+			c.Error(apperrors.Internal("Order items were not created", err))
+			return
+		}
 
-		if err != nil {
-			log.Fatal(err)
+		oc.recorder.Record(c, audit.EntityOrder, order_id, nil, snapshot(oc.orders.FindByID(ctx, order_id)))
+		for _, orderItem := range orderItemsToBeInserted {
+			oc.recorder.Record(c, audit.EntityOrderItem, orderItem.OrderItem_id, nil, orderItem)
 		}
 
-		c.JSON(http.StatusOK, insertedOrderItems)
+		c.JSON(http.StatusCreated, orderItemsToBeInserted)
 	}
 }
 
-func UpdateOrderItem() gin.HandlerFunc {
+// @Summary Update an order item
+// @Tags order-items
+// @Accept json
+// @Produce json
+// @Param order_item_id path string true "Order-item ID"
+// @Param body body models.OrderItem true "Fields to change"
+// @Success 200 {object} models.OrderItem
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/order-items/{order_item_id} [patch]
+func (oc *OrderItemController) UpdateOrderItem() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
@@ -254,48 +287,81 @@ func UpdateOrderItem() gin.HandlerFunc {
 
 		var orderItem models.OrderItem
 
-		filter := bson.M{"order_item_id": orderItemId}
+		if err := c.ShouldBindJSON(&orderItem); err != nil {
+			c.Error(apperrors.Invalid(err))
+			return
+		}
 
 		var updateObj primitive.D
+		var fields []string
 
 		if orderItem.Unit_price != nil {
-			updateObj = append(updateObj, bson.E{Key: "unit_price", Value: orderItem.Unit_price})
+			var num = toFixed(*orderItem.Unit_price, 2)
+			updateObj = append(updateObj, bson.E{Key: "unit_price", Value: num})
+			fields = append(fields, "Unit_price")
 		}
 
 		if orderItem.Quantity != nil {
 			updateObj = append(updateObj, bson.E{Key: "quantity", Value: orderItem.Quantity})
+			fields = append(fields, "Quantity")
 		}
 
 		if orderItem.Food_id != nil {
 			updateObj = append(updateObj, bson.E{Key: "food_id", Value: orderItem.Food_id})
+			fields = append(fields, "Food_id")
 		}
 
-		orderItem.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		updateObj = append(updateObj, bson.E{Key: "update_at", Value: orderItem.Updated_at})
+		if len(updateObj) == 0 {
+			c.Error(apperrors.Invalid(apperrors.ErrNoChanges))
+			return
+		}
 
-		upsert := true
+		// only the fields that were sent are checked against the model's rules
+		if validationErr := validator.New().StructPartial(orderItem, fields...); validationErr != nil {
+			c.Error(apperrors.Invalid(validationErr))
+			return
+		}
 
-		opt := options.UpdateOptions{
-			Upsert: &upsert,
+		orderItem.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: orderItem.Updated_at})
+
+		before, err := oc.orderItems.FindByID(ctx, orderItemId)
+		if err != nil {
+			c.Error(lookupError(err, "Order item was not found", "Error occurred while fetching order item"))
+			return
 		}
 
-		result, err := orderItemCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{
-				{Key: "$set", Value: updateObj},
-			},
-			&opt,
-		)
+		if _, err := oc.orderItems.Update(ctx, orderItemId, updateObj); err != nil {
+			c.Error(apperrors.Internal("Order item was not updated", err))
+			return
+		}
 
+		updated, err := oc.orderItems.FindByID(ctx, orderItemId)
 		if err != nil {
-			msg := "Order item was not updated"
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.Error(apperrors.Internal("Error occurred while fetching order item", err))
 			return
 		}
 
-		defer cancel()
+		oc.recorder.Record(c, audit.EntityOrderItem, orderItemId, before, updated)
+
+		c.JSON(http.StatusOK, updated)
+	}
+}
 
-		c.JSON(http.StatusOK, result)
+// OrderItemOrderCreator opens the order a batch of order items is placed on
+// and returns its id.
+func (oc *OrderItemController) OrderItemOrderCreator(ctx context.Context, order models.Order) (string, error) {
+	order.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	order.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	order.ID = primitive.NewObjectID()
+
+	order.Order_id = order.ID.Hex()
+
+	if _, err := oc.orders.Create(ctx, order); err != nil {
+		return "", err
 	}
+
+	return order.Order_id, nil
 }
@@ -0,0 +1,243 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"golang-restaurant-management/apperrors"
+	"golang-restaurant-management/audit"
+	"golang-restaurant-management/helper"
+	"golang-restaurant-management/mailer"
+	"golang-restaurant-management/repository"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// @Summary Request a password reset code
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body ForgotPasswordRequest true "E-mail of the account"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 429 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Router /api/v1/users/password/forgot [post]
+func (uc *UserController) ForgotPassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		var request ForgotPasswordRequest
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.Invalid(err))
+			return
+		}
+
+		var validate = validator.New()
+		if validationErr := validate.Struct(request); validationErr != nil {
+			c.Error(apperrors.Invalid(validationErr))
+			return
+		}
+
+		// requests are limited per address and per IP whether or not the account
+		// exists, so nobody can flood a mailbox or probe for accounts this way
+		attemptKeys := []string{helper.PasswordResetEmailKey(request.Email), helper.PasswordResetIPKey(c.ClientIP())}
+
+		lockRemaining, err := helper.LoginLockRemaining(ctx, uc.repos.LoginAttempts, attemptKeys...)
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while checking reset requests", err))
+			return
+		}
+
+		if lockRemaining > 0 {
+			rejectLocked(c, lockRemaining, "Too many password reset requests, try again later")
+			return
+		}
+
+		if err := helper.RecordLoginFailure(ctx, uc.repos.LoginAttempts, attemptKeys[0], helper.PasswordResetEmailLimit); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Error recording reset request", "error", err)
+		}
+		if err := helper.RecordLoginFailure(ctx, uc.repos.LoginAttempts, attemptKeys[1], helper.PasswordResetIPLimit); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Error recording reset request", "error", err)
+		}
+
+		// the response is the same whether or not the account exists, so this
+		// endpoint cannot be used to find out which e-mails are registered
+
+		response := MessageResponse{Message: "If the account exists, a reset code has been sent to its e-mail address"}
+
+		user, err := uc.repos.Users.FindByEmail(ctx, request.Email)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusOK, response)
+			return
+		}
+
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while fetching user", err))
+			return
+		}
+
+		// only the newest code works; codes from earlier requests are dropped
+		if err := uc.repos.PasswordResets.DeleteUnused(ctx, user.User_id); err != nil {
+			c.Error(apperrors.Internal("Failed to replace earlier password resets", err))
+			return
+		}
+
+		token, err := helper.CreatePasswordReset(ctx, uc.repos.PasswordResets, user.User_id, *user.Email)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to create password reset", err))
+			return
+		}
+
+		message := mailer.Message{
+			To:      request.Email,
+			Subject: "Reset your password",
+			Body:    fmt.Sprintf("Use this code to reset your password: %s\nIt expires in %d minutes and can only be used once.", token, int(helper.PasswordResetTTL.Minutes())),
+		}
+
+		// a failed delivery is only logged; answering differently would tell
+		// the caller that the account exists
+		if err := uc.mail.Send(message); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Error sending password reset mail", "user_id", user.User_id, "error", err)
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// @Summary Reset a password with a reset code
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body ResetPasswordRequest true "Reset code and new password"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Router /api/v1/users/password/reset [post]
+func (uc *UserController) ResetPassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request ResetPasswordRequest
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.Invalid(err))
+			return
+		}
+
+		var validate = validator.New()
+		if validationErr := validate.Struct(request); validationErr != nil {
+			c.Error(apperrors.Invalid(validationErr))
+			return
+		}
+
+		userId, err := helper.ConsumePasswordReset(c.Request.Context(), uc.repos.PasswordResets, request.Token)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.BadRequest("Invalid or expired reset token"))
+			return
+		}
+
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while checking reset token", err))
+			return
+		}
+
+		before := uc.userSnapshot(c.Request.Context(), userId)
+
+		if err := uc.updatePassword(c.Request.Context(), userId, request.New_password); err != nil {
+			c.Error(apperrors.Internal("Failed to update password", err))
+			return
+		}
+
+		// whoever knew the old password must not stay logged in
+
+		if err := helper.RevokeAllUserTokens(c.Request.Context(), uc.repos, userId); err != nil {
+			c.Error(apperrors.Internal("Failed to revoke tokens", err))
+			return
+		}
+
+		uc.recorder.Record(c, audit.EntityUser, userId, before, uc.userSnapshot(c.Request.Context(), userId))
+
+		c.JSON(http.StatusOK, MessageResponse{Message: "Password has been reset"})
+	}
+}
+
+// @Summary Change the password
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/users/password [patch]
+func (uc *UserController) ChangePassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		var request ChangePasswordRequest
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.Invalid(err))
+			return
+		}
+
+		var validate = validator.New()
+		if validationErr := validate.Struct(request); validationErr != nil {
+			c.Error(apperrors.Invalid(validationErr))
+			return
+		}
+
+		userId := c.GetString("uid")
+
+		user, err := uc.repos.Users.FindByID(ctx, userId)
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while fetching user", err))
+			return
+		}
+
+		passwordIsValid, msg := VerifyPassword(request.Current_password, *user.Password)
+		if !passwordIsValid {
+			c.Error(apperrors.BadRequest(msg))
+			return
+		}
+
+		if err := uc.updatePassword(ctx, userId, request.New_password); err != nil {
+			c.Error(apperrors.Internal("Failed to update password", err))
+			return
+		}
+
+		if err := uc.repos.Sessions.RevokeOthers(ctx, userId, c.GetString("session_id"), time.Now()); err != nil {
+			c.Error(apperrors.Internal("Failed to revoke other sessions", err))
+			return
+		}
+
+		uc.recorder.Record(c, audit.EntityUser, userId, newUserAuditView(user), uc.userSnapshot(ctx, userId))
+
+		c.JSON(http.StatusOK, MessageResponse{Message: "Password has been changed"})
+	}
+}
+
+func (uc *UserController) updatePassword(ctx context.Context, userId, password string) error {
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	hashedPassword, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	_, err = uc.repos.Users.Update(ctx, userId, bson.D{
+		{Key: "password", Value: hashedPassword},
+		{Key: "updated_at", Value: updatedAt},
+	})
+	return err
+}
@@ -0,0 +1,49 @@
+package controller
+
+// MfaCodeRequest confirms a two-factor enrollment with a code from the
+// authenticator app.
+type MfaCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// DisableMfaRequest turns two-factor authentication off. Both the current
+// password and a second factor are required, so a stolen session or password
+// alone cannot remove it.
+type DisableMfaRequest struct {
+	Password      string `json:"password" validate:"required"`
+	Code          string `json:"code" validate:"required_without=Recovery_code"`
+	Recovery_code string `json:"recovery_code" validate:"required_without=Code"`
+}
+
+// LoginMfaRequest exchanges the token from Login plus either a TOTP code or
+// a recovery code for a token pair.
+type LoginMfaRequest struct {
+	Mfa_token     string `json:"mfa_token" validate:"required"`
+	Code          string `json:"code" validate:"required_without=Recovery_code"`
+	Recovery_code string `json:"recovery_code" validate:"required_without=Code"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token        string `json:"token" validate:"required"`
+	New_password string `json:"new_password" validate:"required,min=6"`
+}
+
+type ChangePasswordRequest struct {
+	Current_password string `json:"current_password" validate:"required"`
+	New_password     string `json:"new_password" validate:"required,min=6"`
+}
+
+type SetPinRequest struct {
+	Password string `json:"password" validate:"required"`
+	Pin      string `json:"pin" validate:"required,numeric,min=4,max=6"`
+}
+
+type PinLoginRequest struct {
+	Terminal_token string `json:"terminal_token" validate:"required"`
+	User_id        string `json:"user_id" validate:"required"`
+	Pin            string `json:"pin" validate:"required,numeric"`
+}
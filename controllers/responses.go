@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"golang-restaurant-management/helper"
+	"time"
+)
+
+// MessageResponse is returned by actions that have no resource to return.
+type MessageResponse struct {
+	Message string `json:"message" example:"Logged out successfully"`
+}
+
+// TokenResponse is returned when a session starts or is refreshed.
+type TokenResponse struct {
+	User_id       string `json:"user_id"`
+	Token         string `json:"token"`
+	Refresh_token string `json:"refresh_token"`
+}
+
+// StatusResponse is returned by the liveness probe.
+type StatusResponse struct {
+	Status string `json:"status" example:"ok"`
+}
+
+// MfaEnrollmentResponse holds the secret to add to an authenticator app,
+// also as an otpauth:// URI for a QR code.
+type MfaEnrollmentResponse struct {
+	Secret           string `json:"secret"`
+	Provisioning_uri string `json:"provisioning_uri"`
+}
+
+// RecoveryCodesResponse is the only time the recovery codes are shown.
+type RecoveryCodesResponse struct {
+	Message        string   `json:"message"`
+	Recovery_codes []string `json:"recovery_codes"`
+}
+
+// PinLoginResponse holds a short-lived access token; PIN logins get no
+// refresh token.
+type PinLoginResponse struct {
+	User_id    string `json:"user_id"`
+	Token      string `json:"token"`
+	Expires_in int    `json:"expires_in" example:"900"`
+}
+
+// TerminalRegistrationResponse is the only time the terminal token is shown.
+type TerminalRegistrationResponse struct {
+	Terminal_id    string  `json:"terminal_id"`
+	Name           *string `json:"name"`
+	Terminal_token string  `json:"terminal_token"`
+}
+
+// ApiKeyCreatedResponse is the only time the plain API key is shown.
+type ApiKeyCreatedResponse struct {
+	Api_key_id string     `json:"api_key_id"`
+	Name       *string    `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Role       *string    `json:"role"`
+	Scopes     []string   `json:"scopes"`
+	Expires_at *time.Time `json:"expires_at"`
+	Api_key    string     `json:"api_key"`
+}
+
+// JWKSResponse is a JSON Web Key Set.
+type JWKSResponse struct {
+	Keys []helper.JSONWebKey `json:"keys"`
+}
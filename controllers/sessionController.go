@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"golang-restaurant-management/apperrors"
+	"golang-restaurant-management/models"
+	"golang-restaurant-management/query"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionView struct {
+	models.Session
+	Current bool `json:"current"`
+}
+
+// @Summary List the active sessions of the current user
+// @Tags users
+// @Produce json
+// @Success 200 {object} query.Page[SessionView]
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/users/sessions [get]
+func (uc *UserController) GetSessions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessions, err := uc.repos.Sessions.ListActive(c.Request.Context(), c.GetString("uid"))
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while fetching sessions", err))
+			return
+		}
+
+		currentSessionId := c.GetString("session_id")
+
+		sessionViews := []SessionView{}
+		for _, session := range sessions {
+			sessionViews = append(sessionViews, SessionView{
+				Session: session,
+				Current: session.Session_id == currentSessionId,
+			})
+		}
+
+		c.JSON(http.StatusOK, query.Whole(sessionViews))
+	}
+}
+
+// @Summary Revoke one of the current user's sessions
+// @Tags users
+// @Produce json
+// @Param session_id path string true "Session ID"
+// @Success 200 {object} MessageResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/users/sessions/{session_id} [delete]
+func (uc *UserController) RevokeSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		found, err := uc.repos.Sessions.Revoke(c.Request.Context(), c.Param("session_id"), c.GetString("uid"), time.Now())
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to revoke session", err))
+			return
+		}
+
+		if !found {
+			c.Error(apperrors.NotFound("Session not found"))
+			return
+		}
+
+		c.JSON(http.StatusOK, MessageResponse{Message: "Session revoked"})
+	}
+}
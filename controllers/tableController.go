@@ -2,9 +2,11 @@ package controller
 
 import (
 	"context"
-	"golang-restaurant-management/database"
+	"golang-restaurant-management/apperrors"
+	"golang-restaurant-management/audit"
 	"golang-restaurant-management/models"
-	"log"
+	"golang-restaurant-management/query"
+	"golang-restaurant-management/repository"
 	"net/http"
 	"time"
 
@@ -12,52 +14,85 @@ import (
 	"github.com/go-playground/validator/v10"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var tableCollection *mongo.Collection = database.OpenCollection(database.Client, "tables")
+type TableController struct {
+	tables   repository.TableRepository
+	recorder *audit.Recorder
+}
 
-func GetTables() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+func NewTableController(tables repository.TableRepository, recorder *audit.Recorder) *TableController {
+	return &TableController{tables: tables, recorder: recorder}
+}
 
-		result, err := tableCollection.Find(ctx, bson.D{})
+// tableListSpec is what GET /tables can be filtered and sorted by.
+var tableListSpec = query.Spec{
+	Filters: []query.FilterSpec{
+		{Param: "table_number", Field: "table_number", Type: query.Number},
+		{Param: "number_of_guests", Field: "number_of_guests", Type: query.Number},
+	},
+	Sorts:       []string{"table_number", "number_of_guests", "created_at"},
+	DefaultSort: "table_number",
+}
 
+// @Summary List tables
+// @Tags tables
+// @Produce json
+// @Param limit query int false "Items per page"
+// @Param offset query int false "Items to skip"
+// @Param page query int false "Page number, from 1"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Comma-separated fields, - for descending"
+// @Success 200 {object} query.Page[models.Table]
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/tables [get]
+func (tc *TableController) GetTables() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
 
-		if err != nil {
-			c.JSON(500, gin.H{"error": "Error occurred while fetching tables"})
+		params, ok := listParams(c, tableListSpec)
+		if !ok {
 			return
 		}
 
-		var allTables []bson.M
-		if err = result.All(ctx, &allTables); err != nil {
-			log.Fatal(err)
-			return
-		}
-
-		if len(allTables) == 0 {
-			c.JSON(404, gin.H{"message": "No tables found"})
+		page, err := tc.tables.List(ctx, params)
+		if err != nil {
+			c.Error(listError(err, "Error occurred while fetching tables"))
 			return
 		}
 
-		c.JSON(200, allTables)
+		c.JSON(http.StatusOK, page)
 	}
 }
 
-func GetTable() gin.HandlerFunc {
+// @Summary Get a table
+// @Tags tables
+// @Produce json
+// @Param table_id path string true "Table ID"
+// @Success 200 {object} models.Table
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/tables/{table_id} [get]
+func (tc *TableController) GetTable() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		orderID := c.Param("table_id")
 
-		var table models.Table
-
-		err := tableCollection.FindOne(ctx, bson.M{"table_id": orderID}).Decode(&table)
+		table, err := tc.tables.FindByID(ctx, orderID)
 		defer cancel()
 
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching table"})
+			c.Error(lookupError(err, "Table was not found", "Error occurred while fetching table"))
 			return
 		}
 
@@ -65,15 +100,29 @@ func GetTable() gin.HandlerFunc {
 	}
 }
 
-func CreateTable() gin.HandlerFunc {
+// @Summary Create a table
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Param body body models.Table true "Table"
+// @Success 201 {object} models.Table
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/tables [post]
+func (tc *TableController) CreateTable() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
 
 		var table models.Table
 
-		if err := c.BindJSON(&table); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&table); err != nil {
+			c.Error(apperrors.Invalid(err))
 			return
 		}
 
@@ -81,7 +130,7 @@ func CreateTable() gin.HandlerFunc {
 		validationErr := validate.Struct(table)
 
 		if validationErr != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			c.Error(apperrors.Invalid(validationErr))
 			return
 		}
 
@@ -91,68 +140,94 @@ func CreateTable() gin.HandlerFunc {
 		table.ID = primitive.NewObjectID()
 		table.Table_id = table.ID.Hex()
 
-		result, insertErr := tableCollection.InsertOne(ctx, table)
+		_, insertErr := tc.tables.Create(ctx, table)
 		if insertErr != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while creating table"})
+			c.Error(apperrors.Internal("Error occurred while creating table", insertErr))
 			return
 		}
 
-		c.JSON(http.StatusOK, result)
+		tc.recorder.Record(c, audit.EntityTable, table.Table_id, nil, table)
+
+		c.JSON(http.StatusCreated, table)
 
 	}
 }
 
-func UpdateTable() gin.HandlerFunc {
+// @Summary Update a table
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Param table_id path string true "Table ID"
+// @Param body body models.Table true "Fields to change"
+// @Success 200 {object} models.Table
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/tables/{table_id} [patch]
+func (tc *TableController) UpdateTable() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
 
 		var table models.Table
 
 		tableId := c.Param("table_id")
 
-		if err := c.BindJSON(&table); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&table); err != nil {
+			c.Error(apperrors.Invalid(err))
 			return
 		}
 
 		var updateObj primitive.D
+		var fields []string
 
 		if table.Number_of_guests != nil {
 			updateObj = append(updateObj, bson.E{Key: "number_of_guests", Value: table.Number_of_guests})
+			fields = append(fields, "Number_of_guests")
 		}
 
 		if table.Table_number != nil {
 			updateObj = append(updateObj, bson.E{Key: "table_number", Value: table.Table_number})
+			fields = append(fields, "Table_number")
 		}
 
-		table.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-
-		upsert := true
+		if len(updateObj) == 0 {
+			c.Error(apperrors.Invalid(apperrors.ErrNoChanges))
+			return
+		}
 
-		options := options.UpdateOptions{
-			Upsert: &upsert,
+		// only the fields that were sent are checked against the model's rules
+		if validationErr := validator.New().StructPartial(table, fields...); validationErr != nil {
+			c.Error(apperrors.Invalid(validationErr))
+			return
 		}
 
-		filter := bson.M{"table_id": tableId}
+		table.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: table.Updated_at})
 
-		result, err := tableCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{
-				{Key: "$set", Value: updateObj},
-			},
-			&options,
-		)
+		before, err := tc.tables.FindByID(ctx, tableId)
+		if err != nil {
+			c.Error(lookupError(err, "Table was not found", "Error occurred while fetching table"))
+			return
+		}
+
+		if _, err := tc.tables.Update(ctx, tableId, updateObj); err != nil {
+			c.Error(apperrors.Internal("Table item update failed ", err))
+			return
+		}
 
+		updated, err := tc.tables.FindByID(ctx, tableId)
 		if err != nil {
-			msg := "Table item update failed "
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.Error(apperrors.Internal("Error occurred while fetching table", err))
 			return
 		}
 
-		defer cancel()
+		tc.recorder.Record(c, audit.EntityTable, tableId, before, updated)
 
-		c.JSON(http.StatusOK, result)
+		c.JSON(http.StatusOK, updated)
 	}
 }
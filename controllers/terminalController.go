@@ -0,0 +1,402 @@
+package controller
+
+import (
+	"context"
+	"golang-restaurant-management/apperrors"
+	"golang-restaurant-management/audit"
+	"golang-restaurant-management/helper"
+	"golang-restaurant-management/models"
+	"golang-restaurant-management/query"
+	"golang-restaurant-management/repository"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// PIN logins only last a short while because the tablet is shared
+	pinTokenTTL = 15 * time.Minute
+
+	pinFailureLimit      = 5
+	terminalFailureLimit = 20
+)
+
+// pinLoginRoles are the floor staff roles that may log in with a PIN. A PIN
+// is a much weaker secret than a password and skips two-factor
+// authentication, so managers and admins always use the full login.
+var pinLoginRoles = []string{models.RoleWaiter, models.RoleChef, models.RoleCashier}
+
+// TerminalController serves the endpoints that register and revoke staff
+// terminals. PIN login itself is served by UserController.
+type TerminalController struct {
+	terminals repository.TerminalRepository
+	recorder  *audit.Recorder
+}
+
+func NewTerminalController(terminals repository.TerminalRepository, recorder *audit.Recorder) *TerminalController {
+	return &TerminalController{terminals: terminals, recorder: recorder}
+}
+
+// TerminalView is the audit-safe form of a terminal, without its token hash.
+type TerminalView struct {
+	Terminal_id  string     `json:"terminal_id"`
+	Name         *string    `json:"name"`
+	Created_by   string     `json:"created_by"`
+	Created_at   time.Time  `json:"created_at"`
+	Last_used_at *time.Time `json:"last_used_at"`
+	Revoked_at   *time.Time `json:"revoked_at"`
+}
+
+func newTerminalView(terminal models.Terminal) TerminalView {
+	return TerminalView{
+		Terminal_id:  terminal.Terminal_id,
+		Name:         terminal.Name,
+		Created_by:   terminal.Created_by,
+		Created_at:   terminal.Created_at,
+		Last_used_at: terminal.Last_used_at,
+		Revoked_at:   terminal.Revoked_at,
+	}
+}
+
+// terminalSnapshot returns the audit-safe view of a terminal, or nil when the
+// terminal does not exist.
+func (tc *TerminalController) terminalSnapshot(ctx context.Context, terminalId string) interface{} {
+	terminal, err := tc.terminals.FindByID(ctx, terminalId)
+	if err != nil {
+		return nil
+	}
+
+	return newTerminalView(terminal)
+}
+
+// @Summary Register a terminal
+// @Tags terminals
+// @Accept json
+// @Produce json
+// @Param body body models.Terminal true "Terminal"
+// @Success 201 {object} TerminalRegistrationResponse
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/terminals [post]
+func (tc *TerminalController) RegisterTerminal() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		var terminal models.Terminal
+
+		if err := c.ShouldBindJSON(&terminal); err != nil {
+			c.Error(apperrors.Invalid(err))
+			return
+		}
+
+		var validate = validator.New()
+		if validationErr := validate.Struct(terminal); validationErr != nil {
+			c.Error(apperrors.Invalid(validationErr))
+			return
+		}
+
+		token, err := helper.GenerateSecureToken(32)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to generate terminal token", err))
+			return
+		}
+
+		terminal.ID = primitive.NewObjectID()
+		terminal.Terminal_id = terminal.ID.Hex()
+		terminal.Token_hash = helper.HashToken(token)
+		terminal.Created_by = c.GetString("uid")
+		terminal.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		terminal.Last_used_at = nil
+		terminal.Revoked_at = nil
+
+		if err := tc.terminals.Create(ctx, terminal); err != nil {
+			c.Error(apperrors.Internal("Terminal was not registered", err))
+			return
+		}
+
+		tc.recorder.Record(c, audit.EntityTerminal, terminal.Terminal_id, nil, newTerminalView(terminal))
+
+		// the plain token is stored on the device and never shown again
+		c.JSON(http.StatusCreated, TerminalRegistrationResponse{
+			Terminal_id:    terminal.Terminal_id,
+			Name:           terminal.Name,
+			Terminal_token: token,
+		})
+	}
+}
+
+// @Summary List active terminals
+// @Tags terminals
+// @Produce json
+// @Success 200 {object} query.Page[models.Terminal]
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/terminals [get]
+func (tc *TerminalController) GetTerminals() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		allTerminals, err := tc.terminals.ListActive(ctx)
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while fetching terminals", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, query.Whole(allTerminals))
+	}
+}
+
+// @Summary Revoke a terminal
+// @Tags terminals
+// @Produce json
+// @Param terminal_id path string true "Terminal ID"
+// @Success 200 {object} MessageResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/terminals/{terminal_id} [delete]
+func (tc *TerminalController) RevokeTerminal() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		terminalId := c.Param("terminal_id")
+		revokedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		before := tc.terminalSnapshot(ctx, terminalId)
+
+		revoked, err := tc.terminals.Revoke(ctx, terminalId, revokedAt)
+		if err != nil {
+			c.Error(apperrors.Internal("Terminal was not revoked", err))
+			return
+		}
+
+		if !revoked {
+			c.Error(apperrors.NotFound("Terminal not found"))
+			return
+		}
+
+		tc.recorder.Record(c, audit.EntityTerminal, terminalId, before, tc.terminalSnapshot(ctx, terminalId))
+
+		c.JSON(http.StatusOK, MessageResponse{Message: "Terminal revoked"})
+	}
+}
+
+// @Summary Set the PIN used on terminals
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body SetPinRequest true "Current password and new PIN"
+// @Success 200 {object} MessageResponse
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/users/pin [put]
+func (uc *UserController) SetPin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		var request SetPinRequest
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.Invalid(err))
+			return
+		}
+
+		var validate = validator.New()
+		if validationErr := validate.Struct(request); validationErr != nil {
+			c.Error(apperrors.Invalid(validationErr))
+			return
+		}
+
+		user, err := uc.repos.Users.FindByID(ctx, c.GetString("uid"))
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while fetching user", err))
+			return
+		}
+
+		passwordIsValid, msg := VerifyPassword(request.Password, *user.Password)
+		if !passwordIsValid {
+			c.Error(apperrors.BadRequest(msg))
+			return
+		}
+
+		if !pinLoginAllowed(user) {
+			c.Error(apperrors.Forbidden("This account has to log in with its password"))
+			return
+		}
+
+		hashedPin, err := HashPin(request.Pin)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to set pin", err))
+			return
+		}
+
+		updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		_, err = uc.repos.Users.Update(ctx, user.User_id, bson.D{
+			{Key: "pin", Value: hashedPin},
+			{Key: "updated_at", Value: updatedAt},
+		})
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to set pin", err))
+			return
+		}
+
+		uc.recorder.Record(c, audit.EntityUser, user.User_id, newUserAuditView(user), uc.userSnapshot(ctx, user.User_id))
+
+		c.JSON(http.StatusOK, MessageResponse{Message: "PIN has been set"})
+	}
+}
+
+// PinLogin lets floor staff log in on a registered terminal with their PIN.
+// It only issues a short-lived access token, never a refresh token, and
+// refuses managers, admins and accounts with two-factor authentication.
+//
+// @Summary Log in on a terminal with a PIN
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body PinLoginRequest true "Terminal token, user and PIN"
+// @Success 200 {object} PinLoginResponse
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 429 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Router /api/v1/users/pin-login [post]
+func (uc *UserController) PinLogin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		var request PinLoginRequest
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.Invalid(err))
+			return
+		}
+
+		var validate = validator.New()
+		if validationErr := validate.Struct(request); validationErr != nil {
+			c.Error(apperrors.Invalid(validationErr))
+			return
+		}
+
+		terminal, err := uc.repos.Terminals.FindActiveByTokenHash(ctx, helper.HashToken(request.Terminal_token))
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.Unauthorized("Unknown or revoked terminal"))
+			return
+		}
+
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while fetching terminal", err))
+			return
+		}
+
+		// a PIN has very few combinations, so both the user and the terminal
+		// get locked after a handful of wrong guesses
+
+		pinKey := "pin:" + request.User_id
+		terminalKey := "terminal:" + terminal.Terminal_id
+
+		lockRemaining, err := helper.LoginLockRemaining(ctx, uc.repos.LoginAttempts, pinKey, terminalKey)
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while checking login attempts", err))
+			return
+		}
+
+		if lockRemaining > 0 {
+			rejectLockedLogin(c, lockRemaining)
+			return
+		}
+
+		user, err := uc.repos.Users.FindByID(ctx, request.User_id)
+		if err != nil || user.Pin == nil || bcrypt.CompareHashAndPassword([]byte(*user.Pin), []byte(request.Pin)) != nil {
+			if err := helper.RecordLoginFailure(ctx, uc.repos.LoginAttempts, pinKey, pinFailureLimit); err != nil {
+				slog.ErrorContext(c.Request.Context(), "Error recording login failure", "error", err)
+			}
+			if err := helper.RecordLoginFailure(ctx, uc.repos.LoginAttempts, terminalKey, terminalFailureLimit); err != nil {
+				slog.ErrorContext(c.Request.Context(), "Error recording login failure", "error", err)
+			}
+			c.Error(apperrors.Unauthorized("Invalid user or PIN"))
+			return
+		}
+
+		if !isActive(user) {
+			c.Error(apperrors.Forbidden("Account has been deactivated"))
+			return
+		}
+
+		if !pinLoginAllowed(user) {
+			c.Error(apperrors.Forbidden("This account has to log in with its password"))
+			return
+		}
+
+		if err := helper.ResetLoginAttempts(ctx, uc.repos.LoginAttempts, pinKey); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Error resetting login attempts", "error", err)
+		}
+
+		token, err := helper.GenerateAccessToken(*user.Email, emailVerified(user), *user.First_name, *user.Last_name, user.User_id, userRole(user), pinTokenTTL)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to generate token", err))
+			return
+		}
+
+		lastUsedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		if err := uc.repos.Terminals.Touch(ctx, terminal.Terminal_id, lastUsedAt); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Error updating terminal", "error", err)
+		}
+
+		c.JSON(http.StatusOK, PinLoginResponse{
+			User_id:    user.User_id,
+			Token:      token,
+			Expires_in: int(pinTokenTTL.Seconds()),
+		})
+	}
+}
+
+// pinLoginAllowed reports whether the user may log in with a PIN: only floor
+// staff without two-factor authentication can.
+func pinLoginAllowed(user models.User) bool {
+	if mfaEnabled(user) {
+		return false
+	}
+
+	role := userRole(user)
+	for _, allowed := range pinLoginRoles {
+		if role == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// HashPin hashes a PIN with a lower bcrypt cost than passwords so logging in
+// on a terminal stays quick; lockouts protect the small key space.
+func HashPin(pin string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
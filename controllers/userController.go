@@ -2,114 +2,221 @@ package controller
 
 import (
 	"context"
-	"golang-restaurant-management/database"
+	"golang-restaurant-management/apperrors"
+	"golang-restaurant-management/audit"
 	helper "golang-restaurant-management/helper"
+	"golang-restaurant-management/mailer"
 	"golang-restaurant-management/models"
-	"log"
+	"golang-restaurant-management/query"
+	"golang-restaurant-management/repository"
+	"log/slog"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var userCollection *mongo.Collection = database.OpenCollection(database.Client, "user")
+// UserController serves the account, password, e-mail verification,
+// two-factor and PIN login endpoints.
+type UserController struct {
+	repos      *repository.Repositories
+	recorder   *audit.Recorder
+	mail       mailer.Mailer
+	appBaseURL string
+}
 
-type ErrorResponse struct {
-	Error string `json:"error"`
+// NewUserController takes the mailer that delivers account e-mails and the
+// public base URL their links point at.
+func NewUserController(repos *repository.Repositories, recorder *audit.Recorder, mail mailer.Mailer, appBaseURL string) *UserController {
+	return &UserController{repos: repos, recorder: recorder, mail: mail, appBaseURL: appBaseURL}
 }
 
-func GetUsers() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 10000)
+// UserView is what the API returns for a user. It leaves out the password
+// hash, tokens and second-factor secrets stored on models.User.
+type UserView struct {
+	User_id        string     `json:"user_id"`
+	First_name     *string    `json:"first_name"`
+	Last_name      *string    `json:"last_name"`
+	Email          *string    `json:"email"`
+	Phone          *string    `json:"phone"`
+	Avatar         *string    `json:"avatar"`
+	Role           string     `json:"role"`
+	Email_verified bool       `json:"email_verified"`
+	Mfa_enabled    bool       `json:"mfa_enabled"`
+	Active         bool       `json:"active"`
+	Deactivated_at *time.Time `json:"deactivated_at"`
+	Created_at     time.Time  `json:"created_at"`
+	Updated_at     time.Time  `json:"updated_at"`
+}
 
-		defer cancel()
+func newUserView(user models.User) UserView {
+	return UserView{
+		User_id:        user.User_id,
+		First_name:     user.First_name,
+		Last_name:      user.Last_name,
+		Email:          user.Email,
+		Phone:          user.Phone,
+		Avatar:         user.Avatar,
+		Role:           userRole(user),
+		Email_verified: emailVerified(user),
+		Mfa_enabled:    mfaEnabled(user),
+		Active:         isActive(user),
+		Deactivated_at: user.Deactivated_at,
+		Created_at:     user.Created_at,
+		Updated_at:     user.Updated_at,
+	}
+}
 
-		recordPerPage, err := strconv.Atoi(c.Query("recordPerPage"))
+// UserAuditView is the audit-safe form of a user: its UserView plus its
+// security state, without the password, PIN or two-factor secrets.
+type UserAuditView struct {
+	UserView          `bson:",inline"`
+	Pin_set           bool       `json:"pin_set"`
+	Recovery_codes    int        `json:"recovery_codes"`
+	Tokens_revoked_at *time.Time `json:"tokens_revoked_at"`
+}
 
-		if err != nil || recordPerPage < 1 {
-			recordPerPage = 10
-		}
+func newUserAuditView(user models.User) UserAuditView {
+	return UserAuditView{
+		UserView:          newUserView(user),
+		Pin_set:           user.Pin != nil,
+		Recovery_codes:    len(user.Mfa_recovery_codes),
+		Tokens_revoked_at: user.Tokens_revoked_at,
+	}
+}
 
-		page, err1 := strconv.Atoi(c.Query("page"))
-		if err1 != nil || page < 1 {
-			page = 1
-		}
+// userListSpec is what GET /users can be sorted by; the user specific
+// filters are read into a repository.UserFilter.
+var userListSpec = query.Spec{
+	Sorts:        []string{"first_name", "last_name", "email", "created_at"},
+	DefaultSort:  "created_at",
+	DefaultLimit: 10,
+	MaxLimit:     100,
+}
 
-		startIndex := (page - 1) * recordPerPage
-		if s := c.Query("startIndex"); s != "" {
-			startIndex, err = strconv.Atoi(s)
-			if err != nil {
-				startIndex = (page - 1) * recordPerPage
-			}
+// @Summary List users
+// @Tags users
+// @Produce json
+// @Param limit query int false "Items per page"
+// @Param offset query int false "Items to skip"
+// @Param page query int false "Page number, from 1"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Comma-separated fields, - for descending"
+// @Success 200 {object} query.Page[UserView]
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/users [get]
+func (uc *UserController) GetUsers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
+
+		defer cancel()
+
+		params, ok := listParams(c, userListSpec)
+		if !ok {
+			return
 		}
 
-		matchStage := bson.D{{Key: "$match", Value: bson.D{}}}
+		// search matches the start of names, e-mail and phone, case-insensitively
 
-		projectStage := bson.D{
-			{Key: "$project", Value: bson.D{
-				{Key: "_id", Value: 0},
-				{Key: "total_count", Value: 1},
-				{Key: "user_items", Value: bson.D{{Key: "$slice", Value: []interface{}{"$user_items", startIndex, recordPerPage}}}},
-			}}}
+		filter := repository.UserFilter{
+			Search: strings.TrimSpace(c.Query("search")),
+			Role:   strings.ToUpper(c.Query("role")),
+		}
 
-		result, err := userCollection.Aggregate(ctx, mongo.Pipeline{
-			matchStage,
-			projectStage,
-		})
+		switch c.Query("active") {
+		case "true":
+			active := true
+			filter.Active = &active
+		case "false":
+			active := false
+			filter.Active = &active
+		}
 
+		page, err := uc.repos.Users.List(ctx, filter, params)
 		if err != nil {
-			c.JSON(500, gin.H{"error": "Error occurred while fetching users"})
+			c.Error(listError(err, "Error occurred while fetching users"))
 			return
 		}
 
-		var allUsers []bson.M
-		if err = result.All(ctx, &allUsers); err != nil {
-			log.Fatal(err)
-		}
-
-		c.JSON(http.StatusOK, allUsers[0])
+		c.JSON(http.StatusOK, query.MapPage(page, newUserView))
 	}
 }
 
-func GetUser() gin.HandlerFunc {
+// @Summary Get a user
+// @Tags users
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {object} UserView
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/users/{user_id} [get]
+func (uc *UserController) GetUser() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 
 		defer cancel()
 
 		userId := c.Param("user_id")
 
-		var user models.User
+		if !canManageUser(c, userId) {
+			c.Error(apperrors.Forbidden("You are not allowed to view this user"))
+			return
+		}
 
-		err := userCollection.FindOne(ctx, bson.M{"user_id": userId}).Decode(&user)
+		user, err := uc.repos.Users.FindByID(ctx, userId)
+
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("User not found"))
+			return
+		}
 
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching user"})
+			c.Error(apperrors.Internal("Error occurred while fetching user", err))
 			return
 		}
 
-		c.JSON(http.StatusOK, user)
+		c.JSON(http.StatusOK, newUserView(user))
 
 	}
 }
 
-func SignUp() gin.HandlerFunc {
+// @Summary Create an account
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body models.User true "User"
+// @Success 201 {object} TokenResponse
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 409 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Router /api/v1/users/signup [post]
+func (uc *UserController) SignUp() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
 
 		var user models.User
 
 		// convert the JSON data coming from postman to something that golang understands
 
-		if err := c.BindJSON(&user); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&user); err != nil {
+			c.Error(apperrors.Invalid(err))
 			return
 		}
 
@@ -118,43 +225,67 @@ func SignUp() gin.HandlerFunc {
 		validationErr := validate.Struct(user)
 
 		if validationErr != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			c.Error(apperrors.Invalid(validationErr))
 			return
 		}
 
 		// check if the email already exists in the database
 
-		count, err := userCollection.CountDocuments(ctx, bson.M{"email": user.Email})
+		emailExists, err := uc.repos.Users.EmailExists(ctx, *user.Email, "")
 		if err != nil {
-			log.Panic(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while checking email"})
+			c.Error(apperrors.Internal("Error occurred while checking email", err))
 			return
 		}
 
-		if count > 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Email already exists"})
+		if emailExists {
+			c.Error(apperrors.Conflict("Email already exists"))
 			return
 		}
 
 		// hash the password
 
-		password := HashPassword(*user.Password)
+		password, err := HashPassword(*user.Password)
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while hashing password", err))
+			return
+		}
 		user.Password = &password
 
 		// you'll also check if the phone no. has already been used by another account
 
-		countPhone, err := userCollection.CountDocuments(ctx, bson.M{"phone": user.Phone})
+		phoneExists, err := uc.repos.Users.PhoneExists(ctx, *user.Phone, "")
 		if err != nil {
-			log.Panic(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while checking phone number"})
+			c.Error(apperrors.Internal("Error occurred while checking phone number", err))
 			return
 		}
 
-		if countPhone > 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Phone number already exists"})
+		if phoneExists {
+			c.Error(apperrors.Conflict("Phone number already exists"))
 			return
 		}
 
+		// roles are assigned by an admin; the very first account bootstraps the
+		// admin role and every later one has none until an admin gives it one
+
+		totalUsers, err := uc.repos.Users.Count(ctx)
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while checking existing users", err))
+			return
+		}
+
+		user.Role = nil
+		if totalUsers == 0 {
+			role := models.RoleAdmin
+			user.Role = &role
+		}
+
+		verified := false
+		user.Email_verified = &verified
+
+		mfaDisabled := false
+		user.Mfa_enabled = &mfaDisabled
+		user.Deactivated_at = nil
+
 		// create some extra details - createdAt, updatedAt, etc, ID
 
 		user.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
@@ -162,155 +293,817 @@ func SignUp() gin.HandlerFunc {
 		user.ID = primitive.NewObjectID()
 		user.User_id = user.ID.Hex()
 
-		// generate token and refresh token (gen all tokens from helper function )
-
-		token, refreshToken, _ := helper.GenerateAllTokens(*user.Email, *user.First_name, *user.Last_name, user.User_id)
-		user.Token = &token
-		user.Refresh_token = &refreshToken
-
 		// if all ok, then you insert this new user into the user collection
 
-		_, insertErr := userCollection.InsertOne(ctx, user)
+		insertErr := uc.repos.Users.Create(ctx, user)
 		if insertErr != nil {
 			msg := "User item was not created"
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.Error(apperrors.Internal(msg, insertErr))
 			return
 		}
 
-		// return status OK and send the result back
+		uc.recorder.Record(c, audit.EntityUser, user.User_id, nil, newUserAuditView(user))
 
-		c.JSON(http.StatusOK, gin.H{
-			"user_id":       user.User_id,
-			"token":         token,
-			"refresh_token": refreshToken,
+		// the account stays unverified until the link in this e-mail is opened
+
+		if err := uc.sendEmailVerification(ctx, user); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Error sending verification mail", "error", err)
+		}
+
+		// open a session for this device and generate its token pair
+
+		token, refreshToken, err := uc.startSession(c, user)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to generate tokens", err))
+			return
+		}
+
+		// return 201 Created with the session of the new account
+
+		c.JSON(http.StatusCreated, TokenResponse{
+			User_id:       user.User_id,
+			Token:         token,
+			Refresh_token: refreshToken,
 		})
 	}
 }
 
-func Login() gin.HandlerFunc {
+// @Summary Log in
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body models.User true "E-mail and password"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 429 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Router /api/v1/users/login [post]
+func (uc *UserController) Login() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
 		var user models.User
 		var foundUser models.User
 
 		// convert the login data from postman which is in JSON format to golang readable format
 
-		if err := c.BindJSON(&user); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&user); err != nil {
+			c.Error(apperrors.Invalid(err))
+			return
+		}
+
+		if user.Email == nil || user.Password == nil {
+			c.Error(apperrors.BadRequest("email and password are required"))
+			return
+		}
+
+		// refuse locked e-mails and IPs before spending time on bcrypt
+
+		attemptKeys := []string{helper.EmailAttemptKey(*user.Email), helper.IPAttemptKey(c.ClientIP())}
+
+		lockRemaining, err := helper.LoginLockRemaining(ctx, uc.repos.LoginAttempts, attemptKeys...)
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while checking login attempts", err))
+			return
+		}
+
+		if lockRemaining > 0 {
+			rejectLockedLogin(c, lockRemaining)
 			return
 		}
 
 		// find a user with the email and see if it exists
 
-		err := userCollection.FindOne(ctx, bson.M{"email": user.Email}).Decode(&foundUser)
-		defer cancel()
+		// an unknown e-mail and a wrong password get the same answer, so the
+		// response does not reveal which accounts exist
+
+		foundUser, err = uc.repos.Users.FindByEmail(ctx, *user.Email)
+		if err == repository.ErrNotFound {
+			uc.recordLoginFailure(c, *user.Email)
+			c.Error(apperrors.Unauthorized("invalid email or password"))
+			return
+		}
 
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
+			c.Error(apperrors.Internal("Error occurred while finding user", err))
 			return
 		}
 
 		// then verify the password
 
-		passwordIsValid, msg := VerifyPassword(*user.Password, *foundUser.Password)
-		defer cancel()
+		passwordIsValid, _ := VerifyPassword(*user.Password, *foundUser.Password)
 		if !passwordIsValid {
-			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			uc.recordLoginFailure(c, *user.Email)
+			c.Error(apperrors.Unauthorized("invalid email or password"))
 			return
 		}
 
-		// if all goes well, generate the tokens
+		if !isActive(foundUser) {
+			c.Error(apperrors.Forbidden("Account has been deactivated"))
+			return
+		}
 
-		token, refreshToken, generateErr := helper.GenerateAllTokens(*foundUser.Email, *foundUser.First_name, *foundUser.Last_name, foundUser.User_id)
+		if err := helper.ResetLoginAttempts(ctx, uc.repos.LoginAttempts, helper.EmailAttemptKey(*user.Email)); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Error resetting login attempts", "error", err)
+		}
+
+		// accounts with two-factor authentication get a short-lived token that
+		// has to be exchanged together with a code at /user/login/mfa
+
+		if mfaEnabled(foundUser) {
+			mfaToken, err := helper.GenerateMfaToken(foundUser.User_id)
+			if err != nil {
+				c.Error(apperrors.Internal("Failed to generate tokens", err))
+				return
+			}
 
-		if generateErr != nil {
-			log.Printf("Error generating tokens: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+			c.JSON(http.StatusOK, gin.H{
+				"user_id":      foundUser.User_id,
+				"mfa_required": true,
+				"mfa_token":    mfaToken,
+			})
 			return
 		}
 
-		// update tokens - token and refresh token
+		// if all goes well, open a session for this device and generate its tokens
 
-		if err := helper.UpdateAllTokens(token, refreshToken, foundUser.User_id); err != nil {
-			log.Printf("Error updating tokens: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tokens"})
+		token, refreshToken, err := uc.startSession(c, foundUser)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to generate tokens", err))
 			return
 		}
 
 		// return status okn
-		c.JSON(http.StatusOK, gin.H{
-			"user_id":       foundUser.User_id,
-			"token":         token,
-			"refresh_token": refreshToken,
+		c.JSON(http.StatusOK, TokenResponse{
+			User_id:       foundUser.User_id,
+			Token:         token,
+			Refresh_token: refreshToken,
 		})
 	}
 }
 
-func RefreshToken() gin.HandlerFunc {
+// @Summary Refresh a session
+// @Tags users
+// @Accept json
+// @Produce json
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Router /api/v1/users/refresh-token [post]
+func (uc *UserController) RefreshToken() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
 		defer cancel()
 
 		var request struct {
 			RefreshToken string `json:"refresh_token" binding:"required"`
 		}
 
-		if err := c.BindJSON(&request); err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.Invalid(err))
 			return
 		}
 
 		claims, msg := helper.ValidateToken(request.RefreshToken)
 		if msg != "" {
-			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: msg})
+			c.Error(apperrors.Unauthorized(msg))
 			return
 		}
 
 		if claims.TokenType != "refresh" {
-			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid token type, refresh token required"})
+			c.Error(apperrors.Unauthorized("Invalid token type, refresh token required"))
+			return
+		}
+
+		revoked, err := helper.IsTokenRevoked(ctx, uc.repos, claims)
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while checking token", err))
 			return
 		}
 
-		var user struct {
-			RefreshToken string `bson:"refresh_token"`
+		if revoked {
+			c.Error(apperrors.Unauthorized("Invalid or revoked refresh token"))
+			return
 		}
 
-		err := userCollection.FindOne(ctx, bson.M{"user_id": claims.Uid}).Decode(&user)
-		if err != nil || user.RefreshToken != request.RefreshToken {
-			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid or revoked refresh token"})
+		session, err := uc.repos.Sessions.FindByID(ctx, claims.Session_id)
+		if err != nil || session.User_id != claims.Uid {
+			c.Error(apperrors.Unauthorized("Invalid or revoked refresh token"))
 			return
 		}
 
-		token, refreshToken, err := helper.GenerateAllTokens(claims.Email, claims.First_name, claims.Last_name, claims.Uid)
+		// a validly signed token of this session that is no longer the current
+		// one has already been rotated, so somebody is replaying it
+
+		presentedHash := helper.HashToken(request.RefreshToken)
+
+		if session.Refresh_token_hash != presentedHash {
+			uc.revokeTokenFamily(c, session, "rotated refresh token was presented again")
+			c.Error(apperrors.Unauthorized("Invalid or revoked refresh token"))
+			return
+		}
+
+		user, err := uc.repos.Users.FindByID(ctx, claims.Uid)
 		if err != nil {
-			log.Printf("Error generating tokens: %v", err)
-			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to generate tokens"})
+			c.Error(apperrors.Unauthorized("Invalid or revoked refresh token"))
 			return
 		}
 
-		if err := helper.UpdateAllTokens(token, refreshToken, claims.Uid); err != nil {
-			log.Printf("Error updating tokens: %v", err)
-			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update tokens"})
+		token, refreshToken, err := helper.GenerateAllTokens(*user.Email, emailVerified(user), mfaEnabled(user), *user.First_name, *user.Last_name, user.User_id, userRole(user), session.Session_id)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to generate tokens", err))
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
-			"user_id":       claims.Uid,
-			"token":         token,
-			"refresh_token": refreshToken,
+		lastUsedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		rotated, err := uc.repos.Sessions.Rotate(ctx, session.Session_id, presentedHash, helper.HashToken(refreshToken), c.ClientIP(), lastUsedAt)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to update tokens", err))
+			return
+		}
+
+		if !rotated {
+			uc.revokeTokenFamily(c, session, "refresh token was rotated concurrently by another request")
+			c.Error(apperrors.Unauthorized("Invalid or revoked refresh token"))
+			return
+		}
+
+		c.JSON(http.StatusOK, TokenResponse{
+			User_id:       claims.Uid,
+			Token:         token,
+			Refresh_token: refreshToken,
 		})
 	}
 }
 
-func HashPassword(password string) string {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+// @Summary Update a user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Param body body models.User true "Fields to change"
+// @Success 200 {object} UserView
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 409 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/v1/users/{user_id} [patch]
+func (uc *UserController) UpdateUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		userId := c.Param("user_id")
+
+		if !canManageUser(c, userId) {
+			c.Error(apperrors.Forbidden("You are not allowed to update this user"))
+			return
+		}
+
+		var user models.User
+
+		if err := c.ShouldBindJSON(&user); err != nil {
+			c.Error(apperrors.Invalid(err))
+			return
+		}
+
+		foundUser, err := uc.repos.Users.FindByID(ctx, userId)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("User not found"))
+			return
+		}
+
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while fetching user", err))
+			return
+		}
+
+		var validate = validator.New()
+		var updateObj primitive.D
+
+		if user.First_name != nil {
+			if err := validate.Var(*user.First_name, "min=2,max=100"); err != nil {
+				c.Error(apperrors.BadRequest("first_name must be between 2 and 100 characters"))
+				return
+			}
+			updateObj = append(updateObj, bson.E{Key: "first_name", Value: user.First_name})
+		}
+
+		if user.Last_name != nil {
+			if err := validate.Var(*user.Last_name, "min=2,max=100"); err != nil {
+				c.Error(apperrors.BadRequest("last_name must be between 2 and 100 characters"))
+				return
+			}
+			updateObj = append(updateObj, bson.E{Key: "last_name", Value: user.Last_name})
+		}
+
+		if user.Avatar != nil {
+			updateObj = append(updateObj, bson.E{Key: "avatar", Value: user.Avatar})
+		}
+
+		if user.Phone != nil && (foundUser.Phone == nil || *user.Phone != *foundUser.Phone) {
+			if err := validate.Var(*user.Phone, "required"); err != nil {
+				c.Error(apperrors.BadRequest("phone must not be empty"))
+				return
+			}
+
+			phoneExists, err := uc.repos.Users.PhoneExists(ctx, *user.Phone, userId)
+			if err != nil {
+				c.Error(apperrors.Internal("Error occurred while checking phone number", err))
+				return
+			}
+
+			if phoneExists {
+				c.Error(apperrors.Conflict("Phone number already exists"))
+				return
+			}
+
+			updateObj = append(updateObj, bson.E{Key: "phone", Value: user.Phone})
+		}
+
+		emailChanged := user.Email != nil && (foundUser.Email == nil || *user.Email != *foundUser.Email)
+
+		if emailChanged {
+			if err := validate.Var(*user.Email, "required,email"); err != nil {
+				c.Error(apperrors.BadRequest("email must be a valid e-mail address"))
+				return
+			}
+
+			emailExists, err := uc.repos.Users.EmailExists(ctx, *user.Email, userId)
+			if err != nil {
+				c.Error(apperrors.Internal("Error occurred while checking email", err))
+				return
+			}
+
+			if emailExists {
+				c.Error(apperrors.Conflict("Email already exists"))
+				return
+			}
+
+			// a new address has to be verified again
+			updateObj = append(updateObj, bson.E{Key: "email", Value: user.Email})
+			updateObj = append(updateObj, bson.E{Key: "email_verified", Value: false})
+			updateObj = append(updateObj, bson.E{Key: "email_verified_at", Value: nil})
+		}
+
+		user.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: user.Updated_at})
+
+		if _, err := uc.repos.Users.Update(ctx, userId, updateObj); err != nil {
+			c.Error(apperrors.Internal("User update failed", err))
+			return
+		}
+
+		updatedUser, err := uc.repos.Users.FindByID(ctx, userId)
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while fetching user", err))
+			return
+		}
+
+		uc.recorder.Record(c, audit.EntityUser, userId, newUserAuditView(foundUser), newUserAuditView(updatedUser))
+
+		if emailChanged {
+			// links mailed to the old address must not verify the new one
+			if err := uc.repos.EmailVerifications.DeleteUnused(ctx, userId); err != nil {
+				slog.ErrorContext(c.Request.Context(), "Error deleting verification tokens", "error", err)
+			}
+			if err := uc.sendEmailVerification(ctx, updatedUser); err != nil {
+				slog.ErrorContext(c.Request.Context(), "Error sending verification mail", "error", err)
+			}
+		}
+
+		c.JSON(http.StatusOK, newUserView(updatedUser))
+	}
+}
+
+// @Summary Deactivate a user
+// @Tags users
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {object} MessageResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/users/{user_id}/deactivate [post]
+func (uc *UserController) DeactivateUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		userId := c.Param("user_id")
+
+		if !canManageUser(c, userId) {
+			c.Error(apperrors.Forbidden("You are not allowed to deactivate this user"))
+			return
+		}
+
+		deactivatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		before := uc.userSnapshot(ctx, userId)
 
+		deactivated, err := uc.repos.Users.Deactivate(ctx, userId, deactivatedAt)
+
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while deactivating user", err))
+			return
+		}
+
+		if !deactivated {
+			c.Error(apperrors.NotFound("User not found or already deactivated"))
+			return
+		}
+
+		uc.recorder.Record(c, audit.EntityUser, userId, before, uc.userSnapshot(ctx, userId))
+
+		// a deactivated account must not keep working on any device
+
+		if err := helper.RevokeAllUserTokens(ctx, uc.repos, userId); err != nil {
+			c.Error(apperrors.Internal("Failed to revoke tokens", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, MessageResponse{Message: "User has been deactivated"})
+	}
+}
+
+// @Summary Reactivate a user
+// @Tags users
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {object} MessageResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/users/{user_id}/reactivate [post]
+func (uc *UserController) ReactivateUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		userId := c.Param("user_id")
+		updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		before := uc.userSnapshot(ctx, userId)
+
+		reactivated, err := uc.repos.Users.Reactivate(ctx, userId, updatedAt)
+
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while reactivating user", err))
+			return
+		}
+
+		if !reactivated {
+			c.Error(apperrors.NotFound("User not found or not deactivated"))
+			return
+		}
+
+		uc.recorder.Record(c, audit.EntityUser, userId, before, uc.userSnapshot(ctx, userId))
+
+		c.JSON(http.StatusOK, MessageResponse{Message: "User has been reactivated"})
+	}
+}
+
+// @Summary Log out of this session
+// @Tags users
+// @Produce json
+// @Success 200 {object} MessageResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/users/logout [post]
+func (uc *UserController) Logout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId := c.GetString("uid")
+
+		if err := helper.RevokeToken(c.Request.Context(), uc.repos.RevokedTokens, c.GetString("token_id"), userId, c.GetInt64("token_expires_at")); err != nil {
+			c.Error(apperrors.Internal("Failed to revoke token", err))
+			return
+		}
+
+		// end the session so its refresh token cannot be used any more
+
+		if sessionId := c.GetString("session_id"); sessionId != "" {
+			if _, err := uc.repos.Sessions.Revoke(c.Request.Context(), sessionId, userId, time.Now()); err != nil {
+				c.Error(apperrors.Internal("Failed to revoke session", err))
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, MessageResponse{Message: "Logged out successfully"})
+	}
+}
+
+// @Summary Log out of all sessions
+// @Tags users
+// @Produce json
+// @Success 200 {object} MessageResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/users/logout-all [post]
+func (uc *UserController) LogoutAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := helper.RevokeAllUserTokens(c.Request.Context(), uc.repos, c.GetString("uid")); err != nil {
+			c.Error(apperrors.Internal("Failed to revoke tokens", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, MessageResponse{Message: "Logged out from all devices"})
+	}
+}
+
+// @Summary Revoke all sessions of a user
+// @Tags users
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {object} MessageResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/users/{user_id}/revoke-tokens [post]
+func (uc *UserController) RevokeUserTokens() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		userId := c.Param("user_id")
+
+		user, err := uc.repos.Users.FindByID(ctx, userId)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("User not found"))
+			return
+		}
+
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while fetching user", err))
+			return
+		}
+
+		if err := helper.RevokeAllUserTokens(ctx, uc.repos, userId); err != nil {
+			c.Error(apperrors.Internal("Failed to revoke tokens", err))
+			return
+		}
+
+		uc.recorder.Record(c, audit.EntityUser, userId, newUserAuditView(user), uc.userSnapshot(ctx, userId))
+
+		c.JSON(http.StatusOK, MessageResponse{Message: "All sessions of the user have been revoked"})
+	}
+}
+
+// @Summary Change the role of a user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {object} UserView
+// @Failure 400 {object} apperrors.ErrorResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/users/{user_id}/role [patch]
+func (uc *UserController) UpdateUserRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		userId := c.Param("user_id")
+
+		var request struct {
+			Role string `json:"role" validate:"required,eq=ADMIN|eq=MANAGER|eq=WAITER|eq=CHEF|eq=CASHIER"`
+		}
+
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.Error(apperrors.Invalid(err))
+			return
+		}
+
+		var validate = validator.New()
+		if validationErr := validate.Struct(request); validationErr != nil {
+			c.Error(apperrors.Invalid(validationErr))
+			return
+		}
+
+		updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		before := uc.userSnapshot(ctx, userId)
+
+		result, err := uc.repos.Users.Update(ctx, userId, bson.D{
+			{Key: "role", Value: request.Role},
+			{Key: "updated_at", Value: updatedAt},
+		})
+
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while updating role", err))
+			return
+		}
+
+		if result.MatchedCount == 0 {
+			c.Error(apperrors.NotFound("User not found"))
+			return
+		}
+
+		// the role travels in the access token, so tokens issued under the old
+		// role must stop working now rather than when they expire
+		if err := helper.RevokeAllUserTokens(ctx, uc.repos, userId); err != nil {
+			c.Error(apperrors.Internal("Failed to revoke tokens", err))
+			return
+		}
+
+		updatedUser, err := uc.repos.Users.FindByID(ctx, userId)
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while fetching user", err))
+			return
+		}
+
+		uc.recorder.Record(c, audit.EntityUser, userId, before, newUserAuditView(updatedUser))
+
+		c.JSON(http.StatusOK, newUserView(updatedUser))
+	}
+}
+
+// @Summary Clear the login lockout of a user
+// @Tags users
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {object} MessageResponse
+// @Failure 401 {object} apperrors.ErrorResponse
+// @Failure 403 {object} apperrors.ErrorResponse
+// @Failure 404 {object} apperrors.ErrorResponse
+// @Failure 500 {object} apperrors.ErrorResponse
+// @Security BearerAuth
+// @Router /api/v1/users/{user_id}/unlock [post]
+func (uc *UserController) UnlockUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Second)
+		defer cancel()
+
+		user, err := uc.repos.Users.FindByID(ctx, c.Param("user_id"))
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("User not found"))
+			return
+		}
+
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while fetching user", err))
+			return
+		}
+
+		keys := []string{helper.EmailAttemptKey(*user.Email)}
+		if ip := c.Query("ip"); ip != "" {
+			keys = append(keys, helper.IPAttemptKey(ip))
+		}
+
+		if err := helper.ResetLoginAttempts(ctx, uc.repos.LoginAttempts, keys...); err != nil {
+			c.Error(apperrors.Internal("Failed to unlock user", err))
+			return
+		}
+
+		// the lockout lives in the login attempts, so the user itself is unchanged
+		uc.recorder.Record(c, audit.EntityUser, user.User_id, newUserAuditView(user), newUserAuditView(user))
+
+		c.JSON(http.StatusOK, MessageResponse{Message: "User has been unlocked"})
+	}
+}
+
+// recordLoginFailure counts a failed login against both the e-mail address and
+// the client IP.
+func (uc *UserController) recordLoginFailure(c *gin.Context, email string) {
+	if err := helper.RecordLoginFailure(c.Request.Context(), uc.repos.LoginAttempts, helper.EmailAttemptKey(email), helper.EmailFailureThreshold); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error recording login failure", "error", err)
+	}
+
+	if err := helper.RecordLoginFailure(c.Request.Context(), uc.repos.LoginAttempts, helper.IPAttemptKey(c.ClientIP()), helper.IPFailureThreshold); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error recording login failure", "error", err)
+	}
+}
+
+func rejectLockedLogin(c *gin.Context, remaining time.Duration) {
+	rejectLocked(c, remaining, "Too many failed login attempts, try again later")
+}
+
+func rejectLocked(c *gin.Context, remaining time.Duration, message string) {
+	retryAfter := int(math.Ceil(remaining.Seconds()))
+
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.Error(apperrors.TooManyRequests(message))
+}
+
+// revokeTokenFamily ends the session a reused refresh token belongs to, which
+// invalidates every access and refresh token issued for it, and records the
+// incident as a security event.
+func (uc *UserController) revokeTokenFamily(c *gin.Context, session models.Session, details string) {
+	if _, err := uc.repos.Sessions.Revoke(c.Request.Context(), session.Session_id, session.User_id, time.Now()); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error revoking session", "session_id", session.Session_id, "error", err)
+	}
+
+	event := models.SecurityEvent{
+		Type:       models.SecurityEventRefreshTokenReuse,
+		User_id:    session.User_id,
+		Session_id: session.Session_id,
+		Ip_address: c.ClientIP(),
+		User_agent: c.Request.UserAgent(),
+		Details:    details,
+	}
+
+	if err := helper.RecordSecurityEvent(c.Request.Context(), uc.repos.SecurityEvents, event); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error recording security event", "error", err)
+	}
+}
+
+// startSession records a new session for the requesting device and returns the
+// token pair bound to it.
+func (uc *UserController) startSession(c *gin.Context, user models.User) (string, string, error) {
+	sessionId := primitive.NewObjectID()
+
+	token, refreshToken, err := helper.GenerateAllTokens(*user.Email, emailVerified(user), mfaEnabled(user), *user.First_name, *user.Last_name, user.User_id, userRole(user), sessionId.Hex())
+	if err != nil {
+		return "", "", err
+	}
+
+	deviceName := strings.TrimSpace(c.GetHeader("X-Device-Name"))
+	if deviceName == "" {
+		deviceName = c.Request.UserAgent()
+	}
+
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	session := models.Session{
+		ID:                 sessionId,
+		Session_id:         sessionId.Hex(),
+		User_id:            user.User_id,
+		Device_name:        deviceName,
+		Ip_address:         c.ClientIP(),
+		User_agent:         c.Request.UserAgent(),
+		Refresh_token_hash: helper.HashToken(refreshToken),
+		Created_at:         now,
+		Last_used_at:       now,
+	}
+
+	if err := uc.repos.Sessions.Create(c.Request.Context(), session); err != nil {
+		return "", "", err
+	}
+
+	return token, refreshToken, nil
+}
+
+// userSnapshot returns the audit-safe view of a user, or nil when the user
+// does not exist.
+func (uc *UserController) userSnapshot(ctx context.Context, userId string) interface{} {
+	user, err := uc.repos.Users.FindByID(ctx, userId)
+	if err != nil {
+		return nil
+	}
+
+	return newUserAuditView(user)
+}
+
+// canManageUser lets users manage their own account and admins manage anyone.
+func canManageUser(c *gin.Context, userId string) bool {
+	return c.GetString("uid") == userId || c.GetString("role") == models.RoleAdmin
+}
+
+func isActive(user models.User) bool {
+	return user.Deactivated_at == nil
+}
+
+// emailVerified treats accounts created before e-mail verification existed as
+// verified.
+func emailVerified(user models.User) bool {
+	return user.Email_verified == nil || *user.Email_verified
+}
+
+// userRole returns the stored role, or "" for an account that has not been
+// given one yet, which Authorization rejects.
+func userRole(user models.User) string {
+	if user.Role == nil {
+		return ""
+	}
+	return *user.Role
+}
+
+func HashPassword(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
 	if err != nil {
-		log.Panic(err)
+		return "", err
 	}
 
-	return string(bytes)
+	return string(bytes), nil
 }
 
 func VerifyPassword(userPassword string, providePassword string) (bool, string) {
@@ -3,38 +3,61 @@ package database
 import (
 	"context"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
 	"time"
 
-	"github.com/joho/godotenv"
+	"golang-restaurant-management/config"
+
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
-func DBInstance() *mongo.Client {
-
-	if err := godotenv.Load(); err != nil {
-		log.Printf("Warning: Error loading .env file: %v", err)
-	}
-
-	MongoURI := os.Getenv("MONGO_URI")
-
+// DBInstance connects to the configured deployment. Extra options, such as a
+// command monitor, are applied after the URI.
+func DBInstance(cfg config.Mongo, opts ...*options.ClientOptions) (*mongo.Client, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(MongoURI))
+	client, err := mongo.Connect(ctx, append([]*options.ClientOptions{options.Client().ApplyURI(cfg.URI)}, opts...)...)
 	if err != nil {
-		log.Fatal("Error connecting to MongoDB: ", err)
+		return nil, fmt.Errorf("connecting to MongoDB: %w", err)
 	}
 
-	fmt.Println("Connected to MongoDB")
-	return client
+	slog.Info("Connected to MongoDB", "database", cfg.Database)
+	return client, nil
 }
 
-var Client *mongo.Client = DBInstance()
+// Ping checks that the primary of the deployment is reachable.
+func Ping(ctx context.Context, client *mongo.Client) error {
+	return client.Ping(ctx, readpref.Primary())
+}
 
-func OpenCollection(client *mongo.Client, collectionName string) *mongo.Collection {
-	collection := client.Database("restaurant").Collection(collectionName)
-	return collection
+// CombineMonitors fans every command event out to all the monitors, since the
+// driver accepts only one.
+func CombineMonitors(monitors ...*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			for _, monitor := range monitors {
+				if monitor.Started != nil {
+					monitor.Started(ctx, e)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			for _, monitor := range monitors {
+				if monitor.Succeeded != nil {
+					monitor.Succeeded(ctx, e)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			for _, monitor := range monitors {
+				if monitor.Failed != nil {
+					monitor.Failed(ctx, e)
+				}
+			}
+		},
+	}
 }
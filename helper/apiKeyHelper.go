@@ -0,0 +1,85 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"golang-restaurant-management/models"
+	"golang-restaurant-management/repository"
+	"strings"
+	"time"
+)
+
+const (
+	apiKeyPrefix = "rms"
+
+	// last_used_at is written at most this often per key
+	apiKeyTouchInterval = time.Minute
+)
+
+var ErrInvalidApiKey = errors.New("invalid API key")
+
+// GenerateApiKey returns a new key of the form rms_<prefix>_<secret>, its
+// prefix and the hash to store. The prefix only identifies the key in listings;
+// keys are looked up by the hash of the whole key, which is all that is kept.
+func GenerateApiKey() (key, prefix, hash string, err error) {
+	prefix, err = GenerateSecureToken(4)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	secret, err := GenerateSecureToken(32)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	key = apiKeyPrefix + "_" + prefix + "_" + secret
+	return key, prefix, HashToken(key), nil
+}
+
+// ValidateApiKey looks the key up by its hash and checks its expiry and
+// revocation. ErrInvalidApiKey is returned for any key that must not be
+// accepted.
+func ValidateApiKey(ctx context.Context, apiKeys repository.ApiKeyRepository, key string) (models.ApiKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	var apiKey models.ApiKey
+
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyPrefix {
+		return apiKey, ErrInvalidApiKey
+	}
+
+	apiKey, err := apiKeys.FindActiveByHash(ctx, HashToken(key))
+	if err == repository.ErrNotFound {
+		return apiKey, ErrInvalidApiKey
+	}
+	if err != nil {
+		return apiKey, err
+	}
+
+	if apiKey.Expires_at != nil && apiKey.Expires_at.Before(time.Now()) {
+		return apiKey, ErrInvalidApiKey
+	}
+
+	return apiKey, nil
+}
+
+// TouchApiKey records that the key has just been used.
+func TouchApiKey(ctx context.Context, apiKeys repository.ApiKeyRepository, apiKeyId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	return apiKeys.Touch(ctx, apiKeyId, time.Now(), apiKeyTouchInterval)
+}
+
+// ApiKeyAllows reports whether the scopes grant the action on the resource.
+// "<resource>:*" grants both read and write.
+func ApiKeyAllows(scopes []string, resource, action string) bool {
+	for _, scope := range scopes {
+		if scope == resource+":"+action || scope == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package helper
+
+import (
+	"context"
+	"golang-restaurant-management/models"
+	"golang-restaurant-management/repository"
+	"testing"
+	"time"
+)
+
+func TestValidateApiKeyWithSharedPrefix(t *testing.T) {
+	ctx := context.Background()
+	apiKeys := repository.NewMemoryApiKeyRepository()
+
+	// two live keys whose prefixes collide must both still be accepted
+	var keys []string
+	var ids []string
+	for i := 0; i < 2; i++ {
+		key, _, hash, err := GenerateApiKey()
+		if err != nil {
+			t.Fatalf("GenerateApiKey: %v", err)
+		}
+		id, err := GenerateSecureToken(8)
+		if err != nil {
+			t.Fatalf("GenerateSecureToken: %v", err)
+		}
+		if err := apiKeys.Create(ctx, models.ApiKey{
+			Api_key_id: id,
+			Prefix:     "deadbeef",
+			Key_hash:   hash,
+			Created_at: time.Now(),
+		}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		keys = append(keys, key)
+		ids = append(ids, id)
+	}
+
+	for i, key := range keys {
+		apiKey, err := ValidateApiKey(ctx, apiKeys, key)
+		if err != nil {
+			t.Fatalf("ValidateApiKey(key %d): %v", i, err)
+		}
+		if apiKey.Api_key_id != ids[i] {
+			t.Errorf("ValidateApiKey(key %d) = key %s, want %s", i, apiKey.Api_key_id, ids[i])
+		}
+	}
+
+	if _, err := ValidateApiKey(ctx, apiKeys, keys[0]+"x"); err != ErrInvalidApiKey {
+		t.Errorf("ValidateApiKey with a tampered key: got %v, want ErrInvalidApiKey", err)
+	}
+
+	if _, err := apiKeys.Revoke(ctx, ids[0], time.Now()); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, err := ValidateApiKey(ctx, apiKeys, keys[0]); err != ErrInvalidApiKey {
+		t.Errorf("ValidateApiKey with a revoked key: got %v, want ErrInvalidApiKey", err)
+	}
+}
@@ -0,0 +1,98 @@
+package helper
+
+import (
+	"context"
+	"golang-restaurant-management/repository"
+	"time"
+)
+
+const (
+	// failures allowed before an e-mail address or an IP gets locked
+	EmailFailureThreshold = 5
+	IPFailureThreshold    = 20
+
+	// password reset mails allowed before an e-mail address or an IP has to
+	// wait; every request counts, not only failed ones
+	PasswordResetEmailLimit = 3
+	PasswordResetIPLimit    = 10
+
+	loginAttemptWindow = 15 * time.Minute
+	baseLockout        = 30 * time.Second
+	maxLockout         = time.Hour
+)
+
+func EmailAttemptKey(email string) string {
+	return "email:" + email
+}
+
+func IPAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+func PasswordResetEmailKey(email string) string {
+	return "reset:email:" + email
+}
+
+func PasswordResetIPKey(ip string) string {
+	return "reset:ip:" + ip
+}
+
+// LoginLockRemaining returns how long the longest active lock among the given
+// keys still lasts, or zero when none of them is locked.
+func LoginLockRemaining(ctx context.Context, attempts repository.LoginAttemptRepository, keys ...string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	locked, err := attempts.FindLocked(ctx, keys, now)
+	if err != nil {
+		return 0, err
+	}
+
+	var remaining time.Duration
+	for _, attempt := range locked {
+		if left := attempt.Locked_until.Sub(now); left > remaining {
+			remaining = left
+		}
+	}
+
+	return remaining, nil
+}
+
+// RecordLoginFailure counts a failed login for the key and locks it once the
+// threshold is reached. Every further failure doubles the lockout, up to
+// maxLockout. Counters start over once the key has been quiet for
+// loginAttemptWindow.
+func RecordLoginFailure(ctx context.Context, attempts repository.LoginAttemptRepository, key string, threshold int) error {
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	attempt, err := attempts.RecordFailure(ctx, key, now, now.Add(-loginAttemptWindow))
+	if err != nil {
+		return err
+	}
+
+	if attempt.Failed_count < threshold {
+		return nil
+	}
+
+	lockout := maxLockout
+	if shift := attempt.Failed_count - threshold; shift < 8 {
+		lockout = baseLockout << shift
+		if lockout > maxLockout {
+			lockout = maxLockout
+		}
+	}
+
+	return attempts.Lock(ctx, key, now.Add(lockout))
+}
+
+func ResetLoginAttempts(ctx context.Context, attempts repository.LoginAttemptRepository, keys ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	return attempts.Delete(ctx, keys)
+}
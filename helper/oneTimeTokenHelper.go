@@ -0,0 +1,76 @@
+package helper
+
+import (
+	"context"
+	"golang-restaurant-management/models"
+	"golang-restaurant-management/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PasswordResetTTL     = 30 * time.Minute
+	EmailVerificationTTL = 48 * time.Hour
+)
+
+// CreatePasswordReset stores a new reset request for the user and returns the
+// plain token that has to be delivered to them.
+func CreatePasswordReset(ctx context.Context, resets repository.OneTimeTokenRepository, userId, email string) (string, error) {
+	return createOneTimeToken(ctx, resets, userId, email, PasswordResetTTL)
+}
+
+// ConsumePasswordReset marks an unused, unexpired reset token as used and
+// returns the user it was issued for. repository.ErrNotFound is returned when
+// the token is unknown, expired or already used.
+func ConsumePasswordReset(ctx context.Context, resets repository.OneTimeTokenRepository, token string) (string, error) {
+	oneTimeToken, err := consumeOneTimeToken(ctx, resets, token)
+	return oneTimeToken.User_id, err
+}
+
+// CreateEmailVerification stores a new verification of email for the user
+// and returns the plain token that has to be mailed to that address.
+func CreateEmailVerification(ctx context.Context, verifications repository.OneTimeTokenRepository, userId, email string) (string, error) {
+	return createOneTimeToken(ctx, verifications, userId, email, EmailVerificationTTL)
+}
+
+// ConsumeEmailVerification marks an unused, unexpired verification token as
+// used and returns the user and the address it was mailed to.
+func ConsumeEmailVerification(ctx context.Context, verifications repository.OneTimeTokenRepository, token string) (userId, email string, err error) {
+	oneTimeToken, err := consumeOneTimeToken(ctx, verifications, token)
+	return oneTimeToken.User_id, oneTimeToken.Email, err
+}
+
+func createOneTimeToken(ctx context.Context, tokens repository.OneTimeTokenRepository, userId, email string, ttl time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	token, err := GenerateSecureToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	oneTimeToken := models.OneTimeToken{
+		ID:         primitive.NewObjectID(),
+		User_id:    userId,
+		Email:      email,
+		Token_hash: HashToken(token),
+		Expires_at: now.Add(ttl),
+		Created_at: now,
+	}
+
+	if err := tokens.Create(ctx, oneTimeToken); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func consumeOneTimeToken(ctx context.Context, tokens repository.OneTimeTokenRepository, token string) (models.OneTimeToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	return tokens.Consume(ctx, HashToken(token), time.Now())
+}
@@ -0,0 +1,96 @@
+package helper
+
+import (
+	"context"
+	"golang-restaurant-management/models"
+	"golang-restaurant-management/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RevokeToken adds a single token, identified by its JWT ID, to the
+// revocation list. The entry is kept until the token would have expired.
+func RevokeToken(ctx context.Context, revokedTokens repository.RevokedTokenRepository, tokenId, userId string, expiresAt int64) error {
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	revokedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	revoked := models.RevokedToken{
+		ID:         primitive.NewObjectID(),
+		Token_id:   tokenId,
+		User_id:    userId,
+		Expires_at: time.Unix(expiresAt, 0),
+		Revoked_at: revokedAt,
+	}
+
+	return revokedTokens.Create(ctx, revoked)
+}
+
+// RevokeAllUserTokens invalidates every token issued to the user so far and
+// ends all of the user's sessions.
+func RevokeAllUserTokens(ctx context.Context, repos *repository.Repositories, userId string) error {
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	revokedAt := time.Now()
+
+	if err := repos.Sessions.RevokeAll(ctx, userId, revokedAt); err != nil {
+		return err
+	}
+
+	_, err := repos.Users.Update(ctx, userId, bson.D{
+		{Key: "tokens_revoked_at", Value: revokedAt},
+		{Key: "token", Value: nil},
+		{Key: "refresh_token", Value: nil},
+		{Key: "updated_at", Value: revokedAt},
+	})
+	return err
+}
+
+// IsTokenRevoked reports whether the token was revoked on its own, belongs to
+// a revoked session or was issued before the user's last logout from all
+// devices.
+func IsTokenRevoked(ctx context.Context, repos *repository.Repositories, claims *SignedDetails) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	if claims.Session_id != "" {
+		session, err := repos.Sessions.FindByID(ctx, claims.Session_id)
+		if err == repository.ErrNotFound {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if session.Revoked_at != nil || session.User_id != claims.Uid {
+			return true, nil
+		}
+	}
+
+	if claims.Id != "" {
+		revoked, err := repos.RevokedTokens.Exists(ctx, claims.Id)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	user, err := repos.Users.FindByID(ctx, claims.Uid)
+	if err == repository.ErrNotFound {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// IssuedAt only has second precision, so a token from the same second as
+	// the revocation cannot be told apart from one issued just before it and is
+	// rejected as well
+	if user.Tokens_revoked_at != nil && claims.IssuedAt <= user.Tokens_revoked_at.Unix() {
+		return true, nil
+	}
+
+	return false, nil
+}
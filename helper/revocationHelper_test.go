@@ -0,0 +1,51 @@
+package helper
+
+import (
+	"context"
+	"golang-restaurant-management/models"
+	"golang-restaurant-management/repository"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestIsTokenRevokedAtTheSecondOfRevocation(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+
+	revokedAt := time.Date(2024, 5, 1, 12, 0, 0, 900*int(time.Millisecond), time.UTC)
+	user := models.User{
+		ID:                primitive.NewObjectID(),
+		Tokens_revoked_at: &revokedAt,
+	}
+	user.User_id = user.ID.Hex()
+	if err := repos.Users.Create(ctx, user); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		issuedAt time.Time
+		want     bool
+	}{
+		{name: "issued the second before", issuedAt: revokedAt.Add(-time.Second), want: true},
+		{name: "issued in the same second", issuedAt: revokedAt, want: true},
+		{name: "issued the second after", issuedAt: revokedAt.Add(time.Second), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := newClaims("ada@example.com", true, false, "Ada", "Lovelace", user.User_id, models.RoleWaiter, "", "access", time.Hour)
+			claims.IssuedAt = tt.issuedAt.Unix()
+
+			revoked, err := IsTokenRevoked(ctx, repos, claims)
+			if err != nil {
+				t.Fatalf("IsTokenRevoked: %v", err)
+			}
+			if revoked != tt.want {
+				t.Errorf("IsTokenRevoked = %v, want %v", revoked, tt.want)
+			}
+		})
+	}
+}
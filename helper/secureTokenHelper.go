@@ -0,0 +1,32 @@
+package helper
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// GenerateSecureBytes returns size random bytes.
+func GenerateSecureBytes(size int) ([]byte, error) {
+	bytes := make([]byte, size)
+	if _, err := rand.Read(bytes); err != nil {
+		return nil, err
+	}
+	return bytes, nil
+}
+
+// GenerateSecureToken returns a random hex token of the given number of bytes.
+func GenerateSecureToken(size int) (string, error) {
+	bytes, err := GenerateSecureBytes(size)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// HashToken returns the SHA-256 digest of an opaque token. Only the digest is
+// stored, so a leaked database does not leak usable tokens.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,21 @@
+package helper
+
+import (
+	"context"
+	"golang-restaurant-management/models"
+	"golang-restaurant-management/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func RecordSecurityEvent(ctx context.Context, events repository.SecurityEventRepository, event models.SecurityEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	event.ID = primitive.NewObjectID()
+	event.Event_id = event.ID.Hex()
+	event.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	return events.Create(ctx, event)
+}
@@ -0,0 +1,155 @@
+package helper
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// SigningKey is one RSA key from the key directory. Retired keys that only
+// have a public part left still verify tokens issued before the rotation.
+type SigningKey struct {
+	Kid        string
+	PrivateKey *rsa.PrivateKey
+	PublicKey  *rsa.PublicKey
+}
+
+// JSONWebKey is the public half of a SigningKey as published in the JWKS.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+var signingKeys = map[string]*SigningKey{}
+var activeSigningKey *SigningKey
+
+// LoadSigningKeys reads every "<kid>.pem" (RSA private key) and
+// "<kid>.pub.pem" (RSA public key) file in dir. The key named by activeKid
+// signs new tokens; when activeKid is empty the key with the greatest kid is
+// used, so date-based names such as 2024-06-01.pem rotate naturally.
+func LoadSigningKeys(dir, activeKid string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	keys := map[string]*SigningKey{}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".pem") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+
+		if kid := strings.TrimSuffix(name, ".pub.pem"); kid != name {
+			publicKey, err := jwt.ParseRSAPublicKeyFromPEM(data)
+			if err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+			if _, ok := keys[kid]; !ok {
+				keys[kid] = &SigningKey{Kid: kid, PublicKey: publicKey}
+			}
+			continue
+		}
+
+		kid := strings.TrimSuffix(name, ".pem")
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		keys[kid] = &SigningKey{Kid: kid, PrivateKey: privateKey, PublicKey: &privateKey.PublicKey}
+	}
+
+	if activeKid == "" {
+		var kids []string
+		for kid, key := range keys {
+			if key.PrivateKey != nil {
+				kids = append(kids, kid)
+			}
+		}
+		if len(kids) == 0 {
+			return fmt.Errorf("no RSA private key found in %s", dir)
+		}
+		sort.Strings(kids)
+		activeKid = kids[len(kids)-1]
+	}
+
+	active, ok := keys[activeKid]
+	if !ok || active.PrivateKey == nil {
+		return fmt.Errorf("signing key %q has no private key in %s", activeKid, dir)
+	}
+
+	signingKeys = keys
+	activeSigningKey = active
+	return nil
+}
+
+// JSONWebKeySet returns the public keys that verify tokens issued by this
+// service, sorted by kid.
+func JSONWebKeySet() []JSONWebKey {
+	jwks := []JSONWebKey{}
+
+	for _, key := range signingKeys {
+		jwks = append(jwks, JSONWebKey{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: jwt.SigningMethodRS256.Alg(),
+			Kid: key.Kid,
+			N:   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+		})
+	}
+
+	sort.Slice(jwks, func(i, j int) bool { return jwks[i].Kid < jwks[j].Kid })
+	return jwks
+}
+
+// signClaims signs with the active RSA key and tags the token with its kid.
+// Without a key directory it falls back to HS256 with SECRET_KEY.
+func signClaims(claims *SignedDetails) (string, error) {
+	if activeSigningKey == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(SECRET_KEY))
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = activeSigningKey.Kid
+	return token.SignedString(activeSigningKey.PrivateKey)
+}
+
+// verificationKey picks the key a token has to be verified with. HS256 tokens
+// are only accepted while SECRET_KEY is still configured, which lets tokens
+// issued before the switch to RS256 run out.
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodRSA:
+		kid, _ := token.Header["kid"].(string)
+		key, ok := signingKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key: %q", kid)
+		}
+		return key.PublicKey, nil
+	case *jwt.SigningMethodHMAC:
+		if SECRET_KEY == "" {
+			return nil, fmt.Errorf("HS256 tokens are no longer accepted")
+		}
+		return []byte(SECRET_KEY), nil
+	default:
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+}
@@ -1,68 +1,65 @@
 package helper
 
 import (
-	"context"
+	"errors"
 	"fmt"
-	"golang-restaurant-management/database"
-	"log"
-	"os"
+	"golang-restaurant-management/config"
 	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type SignedDetails struct {
-	Email      string
-	First_name string
-	Last_name  string
-	Uid        string
-	TokenType  string
+	Email          string
+	Email_verified bool
+	Mfa_enabled    bool
+	First_name     string
+	Last_name      string
+	Uid            string
+	Role           string
+	Session_id     string
+	TokenType      string
 	jwt.StandardClaims
 }
 
-var userCollection *mongo.Collection = database.OpenCollection(database.Client, "user")
 var SECRET_KEY string
 
-func init() {
-	SECRET_KEY = os.Getenv("SECRET_KEY")
-	if SECRET_KEY == "" {
-		log.Fatal("SECRET_KEY environment variable not set")
-	}
-}
+var (
+	accessTokenTTL  = time.Hour
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
 
-func GenerateAllTokens(email, firstName, lastName, uid string) (signedToken string, refreshToken string, err error) {
-	claims := &SignedDetails{
-		Email:      email,
-		First_name: firstName,
-		Last_name:  lastName,
-		Uid:        uid,
-		TokenType:  "access",
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Hour * time.Duration(1)).Unix(),
-		},
+// ConfigureTokens sets up token signing and lifetimes. It must be called once
+// at startup before any token is issued or validated.
+func ConfigureTokens(cfg config.JWT) error {
+	SECRET_KEY = cfg.SecretKey
+	accessTokenTTL = cfg.AccessTokenTTL
+	refreshTokenTTL = cfg.RefreshTokenTTL
+
+	if cfg.KeyDir != "" {
+		if err := LoadSigningKeys(cfg.KeyDir, cfg.SigningKeyID); err != nil {
+			return fmt.Errorf("loading JWT signing keys: %w", err)
+		}
+		return nil
 	}
 
-	refreshClaims := &SignedDetails{
-		Email:      email,
-		First_name: firstName,
-		Last_name:  lastName,
-		Uid:        uid,
-		TokenType:  "refresh",
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Hour * 24 * time.Duration(30)).Unix(),
-		},
+	if SECRET_KEY == "" {
+		return errors.New("neither a JWT key directory nor a secret key is configured")
 	}
+	return nil
+}
+
+func GenerateAllTokens(email string, emailVerified, mfaEnabled bool, firstName, lastName, uid, role, sessionId string) (signedToken string, refreshToken string, err error) {
+	claims := newClaims(email, emailVerified, mfaEnabled, firstName, lastName, uid, role, sessionId, "access", accessTokenTTL)
+	refreshClaims := newClaims(email, emailVerified, mfaEnabled, firstName, lastName, uid, role, sessionId, "refresh", refreshTokenTTL)
 
-	signedToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(SECRET_KEY))
+	signedToken, err = signClaims(claims)
 	if err != nil {
 		return "", "", err
 	}
 
-	refreshToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString([]byte(SECRET_KEY))
+	refreshToken, err = signClaims(refreshClaims)
 	if err != nil {
 		return "", "", err
 	}
@@ -70,38 +67,55 @@ func GenerateAllTokens(email, firstName, lastName, uid string) (signedToken stri
 	return signedToken, refreshToken, nil
 }
 
-func UpdateAllTokens(signedToken, signedRefreshToken, userId string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
-	defer cancel()
+// GenerateAccessToken issues a single access token with the given lifetime and
+// no refresh token, for logins that should not outlive a short shift on a
+// shared device. Accounts with two-factor authentication never log in this
+// way, so the token does not carry it.
+func GenerateAccessToken(email string, emailVerified bool, firstName, lastName, uid, role string, ttl time.Duration) (string, error) {
+	return signClaims(newClaims(email, emailVerified, false, firstName, lastName, uid, role, "", "access", ttl))
+}
 
-	var updateObj primitive.D
-	updateObj = append(updateObj, bson.E{Key: "token", Value: signedToken})
-	updateObj = append(updateObj, bson.E{Key: "refresh_token", Value: signedRefreshToken})
-	updateObj = append(updateObj, bson.E{Key: "updated_at", Value: time.Now()})
+func newClaims(email string, emailVerified, mfaEnabled bool, firstName, lastName, uid, role, sessionId, tokenType string, ttl time.Duration) *SignedDetails {
+	return &SignedDetails{
+		Email:          email,
+		Email_verified: emailVerified,
+		Mfa_enabled:    mfaEnabled,
+		First_name:     firstName,
+		Last_name:      lastName,
+		Uid:            uid,
+		Role:           role,
+		Session_id:     sessionId,
+		TokenType:      tokenType,
+		StandardClaims: jwt.StandardClaims{
+			Id:        primitive.NewObjectID().Hex(),
+			IssuedAt:  time.Now().Local().Unix(),
+			ExpiresAt: time.Now().Local().Add(ttl).Unix(),
+		},
+	}
+}
 
-	upsert := true
-	filter := bson.M{"user_id": userId}
-	opt := options.UpdateOptions{Upsert: &upsert}
+// GenerateMfaToken issues the short-lived token Login() hands out when the
+// password was correct but a second factor is still required. It can only be
+// exchanged for a token pair at the MFA login endpoint.
+func GenerateMfaToken(uid string) (string, error) {
+	claims := &SignedDetails{
+		Uid:       uid,
+		TokenType: "mfa_pending",
+		StandardClaims: jwt.StandardClaims{
+			Id:        primitive.NewObjectID().Hex(),
+			IssuedAt:  time.Now().Local().Unix(),
+			ExpiresAt: time.Now().Local().Add(5 * time.Minute).Unix(),
+		},
+	}
 
-	_, err := userCollection.UpdateOne(
-		ctx,
-		filter,
-		bson.D{{Key: "$set", Value: updateObj}},
-		&opt,
-	)
-	return err
+	return signClaims(claims)
 }
 
 func ValidateToken(signedToken string) (*SignedDetails, string) {
 	token, err := jwt.ParseWithClaims(
 		signedToken,
 		&SignedDetails{},
-		func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(SECRET_KEY), nil
-		},
+		verificationKey,
 	)
 
 	if err != nil {
@@ -0,0 +1,56 @@
+package helper
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTOTPCodeMatchesRFC6238 checks totpCode against the SHA-1 test vectors
+// from RFC 6238 Appendix B. The RFC lists eight-digit codes; the six-digit
+// codes used here are their last six digits.
+func TestTOTPCodeMatchesRFC6238(t *testing.T) {
+	key := []byte("12345678901234567890")
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+
+	for _, tt := range tests {
+		if got := totpCode(key, tt.unix/totpPeriod); got != tt.want {
+			t.Errorf("totpCode at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTPRejectsReplayedSteps(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret: %v", err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("decoding secret: %v", err)
+	}
+
+	currentStep := time.Now().Unix() / totpPeriod
+	code := totpCode(key, currentStep)
+
+	step, ok := ValidateTOTP(secret, code, 0)
+	if !ok {
+		t.Fatalf("ValidateTOTP rejected the current code")
+	}
+	if _, ok := ValidateTOTP(secret, code, step); ok {
+		t.Errorf("ValidateTOTP accepted a code from a step that was already used")
+	}
+	if _, ok := ValidateTOTP(secret, "000000x", 0); ok {
+		t.Errorf("ValidateTOTP accepted a code of the wrong length")
+	}
+}
@@ -0,0 +1,169 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"golang-restaurant-management/apperrors"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength caps the incoming X-Request-ID that is trusted; longer or
+// non-printable values are replaced with a generated ID.
+const maxRequestIDLength = 128
+
+type contextKey struct{}
+
+// requestFields are attached to every record logged with the request's
+// context. uid is filled in by the authentication middleware, after the
+// request ID, so it is kept behind a pointer and a lock.
+type requestFields struct {
+	mu        sync.RWMutex
+	requestID string
+	uid       string
+}
+
+// Setup makes a JSON slog logger the default for both slog and the standard
+// log package, at the given level ("debug", "info", "warn" or "error").
+func Setup(w io.Writer, level string) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		lvl = slog.LevelInfo
+	}
+
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: lvl})
+	slog.SetDefault(slog.New(contextHandler{Handler: handler}))
+}
+
+// contextHandler adds the request ID, uid and trace ID found in the context to
+// every record, so handlers only have to log with slog.*Context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if fields, ok := ctx.Value(contextKey{}).(*requestFields); ok {
+		fields.mu.RLock()
+		record.AddAttrs(slog.String("request_id", fields.requestID))
+		if fields.uid != "" {
+			record.AddAttrs(slog.String("uid", fields.uid))
+		}
+		fields.mu.RUnlock()
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// RequestID returns the ID of the request the context belongs to.
+func RequestID(ctx context.Context) string {
+	fields, ok := ctx.Value(contextKey{}).(*requestFields)
+	if !ok {
+		return ""
+	}
+	fields.mu.RLock()
+	defer fields.mu.RUnlock()
+	return fields.requestID
+}
+
+// SetUID records the authenticated user on the request's log context.
+func SetUID(ctx context.Context, uid string) {
+	fields, ok := ctx.Value(contextKey{}).(*requestFields)
+	if !ok {
+		return
+	}
+	fields.mu.Lock()
+	fields.uid = uid
+	fields.mu.Unlock()
+}
+
+// Middleware honours an incoming X-Request-ID or generates one, echoes it in
+// the response, and logs one line per request. Errors attached with c.Error
+// are included in that line, which is logged at error level for 5xx
+// responses.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		fields := &requestFields{requestID: requestID}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), contextKey{}, fields))
+		c.Set("request_id", requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.Any("errors", c.Errors.Errors()))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		slog.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	return strings.IndexFunc(id, func(r rune) bool { return r < 0x21 || r > 0x7e }) < 0
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+// Recovery turns a panic in a handler into a 500 internal_error response and
+// logs it with the stack trace and the request's ID.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		slog.ErrorContext(c.Request.Context(), "Panic recovered", "panic", recovered, "stack", string(debug.Stack()))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, apperrors.Internal("Internal server error", nil).Response())
+	})
+}
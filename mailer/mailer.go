@@ -0,0 +1,99 @@
+package mailer
+
+import (
+	"fmt"
+	"golang-restaurant-management/config"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers account e-mails such as password reset links.
+type Mailer interface {
+	Send(message Message) error
+}
+
+// New picks the mailer named by cfg.Driver ("log", "file" or "smtp"). The
+// file mailer appends to cfg.FilePath, which defaults to mail.log.
+func New(cfg config.Mailer) Mailer {
+	switch strings.ToLower(cfg.Driver) {
+	case "file":
+		path := cfg.FilePath
+		if path == "" {
+			path = "mail.log"
+		}
+		return &FileMailer{Path: path}
+	case "smtp":
+		return &SMTPMailer{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.From,
+		}
+	default:
+		return LogMailer{}
+	}
+}
+
+// LogMailer writes every message to the default logger, which is enough for
+// local development.
+type LogMailer struct{}
+
+func (LogMailer) Send(message Message) error {
+	slog.Info("Mail", "to", message.To, "subject", message.Subject, "body", message.Body)
+	return nil
+}
+
+// FileMailer appends every message to a file so it can be inspected later.
+type FileMailer struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (m *FileMailer) Send(message Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	file, err := os.OpenFile(m.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC1123Z), message.To, message.Subject, message.Body)
+	return err
+}
+
+// SMTPMailer delivers every message through an SMTP server, authenticating
+// with PLAIN when Username is set. The connection is upgraded with STARTTLS
+// when the server offers it.
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(message Message) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	body := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		m.From, message.To, message.Subject, time.Now().Format(time.RFC1123Z), message.Body)
+
+	return smtp.SendMail(net.JoinHostPort(m.Host, m.Port), auth, m.From, []string{message.To}, []byte(body))
+}
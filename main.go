@@ -1,58 +1,149 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"os"
+	"time"
 
+	"golang-restaurant-management/audit"
+	"golang-restaurant-management/config"
+	controller "golang-restaurant-management/controllers"
+	"golang-restaurant-management/database"
 	docs "golang-restaurant-management/docs"
 
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"golang-restaurant-management/helper"
+	"golang-restaurant-management/logging"
+	"golang-restaurant-management/mailer"
+	"golang-restaurant-management/metrics"
 	middlewares "golang-restaurant-management/middleware"
+	"golang-restaurant-management/repository"
 	routes "golang-restaurant-management/routes"
+	"golang-restaurant-management/server"
+	"golang-restaurant-management/tracing"
 )
 
+// @title Restaurant Management API
+// @version 1.0
+// @BasePath /
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
 func main() {
 
-	if err := godotenv.Load(); err != nil {
-		log.Printf("Warning: Error loading .env file: %v", err)
+	logging.Setup(os.Stdout, "info")
+
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		fatal("Failed to load configuration", err)
+	}
+
+	logging.Setup(os.Stdout, cfg.LogLevel)
+	if cfg.Env == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	if err := helper.ConfigureTokens(cfg.JWT); err != nil {
+		fatal("Failed to configure tokens", err)
 	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8000"
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
+
+	monitor := database.CombineMonitors(metrics.CommandMonitor(), tracing.CommandMonitor())
+	client, err := database.DBInstance(cfg.Mongo, options.Client().SetMonitor(monitor))
+	if err != nil {
+		fatal("Failed to connect to the database", err)
+	}
+	db := client.Database(cfg.Mongo.Database)
+	// the server still starts while the database is unreachable and reports
+	// it through /readyz; the indexes are created again on the next start
+	indexCtx, cancelIndexes := context.WithTimeout(context.Background(), 30*time.Second)
+	if err := repository.EnsureIndexes(indexCtx, db); err != nil {
+		slog.Error("Failed to create database indexes", "error", err)
+	}
+	cancelIndexes()
+	repos := repository.NewMongoRepositories(db)
+	recorder := audit.NewRecorder(repos.AuditLogs)
+	stopBusinessMetrics, err := metrics.StartBusinessMetrics(repos.Stats)
+	if err != nil {
+		fatal("Failed to register business metrics", err)
 	}
 
+	port := cfg.Port
+
 	router := gin.New()
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
+	router.Use(logging.Middleware())
+	router.Use(logging.Recovery())
+	router.Use(tracing.Middleware(cfg.Tracing.ServiceName))
+	router.Use(metrics.Middleware())
+	router.Use(middlewares.ErrorHandler())
 
 	docs.SwaggerInfo.Title = "Restaurant Management API"
 	docs.SwaggerInfo.Description = "This is a REST API server for a restaurant management system built in Go using Gin."
 	docs.SwaggerInfo.Version = "1.0"
 	docs.SwaggerInfo.Host = "localhost:" + port
-	docs.SwaggerInfo.BasePath = "/api/v1"
+	docs.SwaggerInfo.BasePath = "/"
 	docs.SwaggerInfo.Schemes = []string{"http"}
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
-	routes.UserRoutes(router)
-	router.Use(middlewares.Authentication())
+	routes.HealthRoutes(router, controller.NewHealthController(controller.HealthCheck{
+		Name:  "mongo",
+		Check: func(ctx context.Context) error { return database.Ping(ctx, client) },
+	}))
+	routes.MetricsRoutes(router)
+	routes.WellKnownRoutes(router)
+	// Every API route lives under /api/v1. The paths served before versioning
+	// stay registered on legacy as deprecated aliases.
+	v1 := router.Group("/api/v1")
+	legacy := router.Group("/", middlewares.Deprecated())
+
+	authenticated := middlewares.Authentication(repos)
+	routes.UserRoutes(v1, legacy, controller.NewUserController(repos, recorder, mailer.New(cfg.Mailer), cfg.AppBaseURL), authenticated)
+
+	for _, group := range []*gin.RouterGroup{v1, legacy} {
+		group.Use(authenticated)
+
+		if cfg.RequireEmailVerification {
+			group.Use(middlewares.EmailVerification())
+		}
+	}
+
+	routes.FoodRoutes(v1, legacy, controller.NewFoodController(repos.Foods, repos.Menus, recorder))
+	routes.MenuRoutes(v1, legacy, controller.NewMenuController(repos.Menus, recorder))
+	routes.TableRoutes(v1, legacy, controller.NewTableController(repos.Tables, recorder))
+	routes.OrderRoutes(v1, legacy, controller.NewOrderController(repos.Orders, repos.Tables, recorder))
+	routes.OrderItemRoutes(v1, legacy, controller.NewOrderItemController(repos.OrderItems, repos.Orders, recorder))
+	routes.InvoiceRoutes(v1, legacy, controller.NewInvoiceController(repos.Invoices, repos.Orders, repos.OrderItems, recorder))
+	routes.TerminalRoutes(v1, legacy, controller.NewTerminalController(repos.Terminals, recorder))
+	routes.AuditRoutes(v1, legacy, controller.NewAuditController(repos.AuditLogs))
+	routes.ApiKeyRoutes(v1, legacy, controller.NewApiKeyController(repos.ApiKeys, recorder))
 
-	routes.FoodRoutes(router)
-	routes.MenuRoutes(router)
-	routes.TableRoutes(router)
-	routes.OrderRoutes(router)
-	routes.OrderItemRoutes(router)
-	routes.InvoiceRoutes(router)
+	srv := server.New(cfg.Server, port, router)
+	srv.OnShutdown("tracing", shutdownTracing)
+	srv.OnShutdown("mongo", client.Disconnect)
+	srv.OnShutdown("business metrics", stopBusinessMetrics)
 
-	log.Printf("Server running on http://localhost:%s", port)
-	log.Printf("Swagger docs available at http://localhost:%s/swagger/index.html", port)
+	slog.Info("Server running", "url", "http://localhost:"+port, "swagger", "http://localhost:"+port+"/swagger/index.html")
 
-	if err := router.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	if err := srv.Run(); err != nil {
+		fatal("Failed to start server", err)
 	}
+	slog.Info("Server stopped")
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"golang-restaurant-management/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+const (
+	businessRefreshInterval = 30 * time.Second
+	businessRefreshTimeout  = 5 * time.Second
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	mongoCommandDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mongo_command_duration_seconds",
+		Help:    "MongoDB command latency by command name and outcome.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"command", "outcome"})
+)
+
+// Middleware records the count and latency of every request. Requests are
+// labelled with the route pattern (e.g. /foods/:food_id) rather than the
+// raw path, and unmatched paths share one label, to keep cardinality bounded.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		httpRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		httpRequestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler serves the metrics in the Prometheus text format.
+//
+// @Summary Prometheus metrics
+// @Tags system
+// @Produce plain
+// @Success 200 {string} string "Metrics in the Prometheus text format"
+// @Router /metrics [get]
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+// CommandMonitor times every command the MongoDB driver sends. Pass it to
+// options.Client().SetMonitor when connecting.
+func CommandMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			mongoCommandDuration.WithLabelValues(e.CommandName, "success").Observe(e.Duration.Seconds())
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			mongoCommandDuration.WithLabelValues(e.CommandName, "failure").Observe(e.Duration.Seconds())
+		},
+	}
+}
+
+// StartBusinessMetrics exposes the open orders, unpaid invoices and occupied
+// tables gauges and refreshes them from the repository every 30 seconds in
+// the background, so a scrape never waits on the aggregation behind them. The
+// returned function stops the refreshing; pass it to server.OnShutdown.
+func StartBusinessMetrics(stats repository.StatsRepository) (func(ctx context.Context) error, error) {
+	for _, gauge := range []prometheus.Gauge{openOrders, unpaidInvoices, occupiedTables} {
+		if err := prometheus.Register(gauge); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(businessRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			refreshBusinessMetrics(ctx, stats)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func(shutdownCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-shutdownCtx.Done():
+			return shutdownCtx.Err()
+		}
+	}, nil
+}
+
+var (
+	openOrders     = prometheus.NewGauge(prometheus.GaugeOpts{Name: "restaurant_open_orders", Help: "Orders without a PAID invoice."})
+	unpaidInvoices = prometheus.NewGauge(prometheus.GaugeOpts{Name: "restaurant_unpaid_invoices", Help: "Invoices whose payment status is not PAID."})
+	occupiedTables = prometheus.NewGauge(prometheus.GaugeOpts{Name: "restaurant_occupied_tables", Help: "Tables with at least one open order."})
+)
+
+// refreshBusinessMetrics keeps the previous values when the repository
+// cannot be read, so a short database outage does not zero the gauges.
+func refreshBusinessMetrics(ctx context.Context, stats repository.StatsRepository) {
+	ctx, cancel := context.WithTimeout(ctx, businessRefreshTimeout)
+	defer cancel()
+
+	current, err := stats.Current(ctx)
+	if err != nil {
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			slog.Error("Error collecting business metrics", "error", err)
+		}
+		return
+	}
+
+	openOrders.Set(float64(current.OpenOrders))
+	unpaidInvoices.Set(float64(current.UnpaidInvoices))
+	occupiedTables.Set(float64(current.OccupiedTables))
+}
@@ -0,0 +1,96 @@
+package middlewares
+
+import (
+	"golang-restaurant-management/apperrors"
+	"golang-restaurant-management/helper"
+	"golang-restaurant-management/logging"
+	"golang-restaurant-management/models"
+	"golang-restaurant-management/repository"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const apiVersionPrefix = "/api/v1"
+
+// apiKeyResources maps the resource segments of a route to the scope resource
+// guarding it, for both the /api/v1 paths and their legacy aliases. Routes that
+// are not listed, such as user, terminal, audit and API key management, can
+// never be reached with an API key.
+var apiKeyResources = map[string]string{
+	"foods":            models.ScopeFoods,
+	"menus":            models.ScopeMenus,
+	"menu":             models.ScopeMenus,
+	"tables":           models.ScopeTables,
+	"table":            models.ScopeTables,
+	"orders":           models.ScopeOrders,
+	"order":            models.ScopeOrders,
+	"order-items":      models.ScopeOrderItems,
+	"orderItems":       models.ScopeOrderItems,
+	"orderItem":        models.ScopeOrderItems,
+	"orderItems-order": models.ScopeOrderItems,
+	"invoices":         models.ScopeInvoices,
+	"invoice":          models.ScopeInvoices,
+}
+
+// authenticateApiKey is the API key branch of Authentication(). The key acts
+// with the role it was created with, limited to its scopes.
+func authenticateApiKey(c *gin.Context, apiKeys repository.ApiKeyRepository, key string) {
+	apiKey, err := helper.ValidateApiKey(c.Request.Context(), apiKeys, key)
+	if err == helper.ErrInvalidApiKey {
+		c.Error(apperrors.Unauthorized("Invalid API key"))
+		c.Abort()
+		return
+	}
+	if err != nil {
+		c.Error(apperrors.Internal("Error occurred while checking API key", err))
+		c.Abort()
+		return
+	}
+
+	resource, action := apiKeyScope(c)
+	if resource == "" || !helper.ApiKeyAllows(apiKey.Scopes, resource, action) {
+		c.Error(apperrors.Forbidden("API key is not allowed to access this resource"))
+		c.Abort()
+		return
+	}
+
+	if err := helper.TouchApiKey(c.Request.Context(), apiKeys, apiKey.Api_key_id); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error updating API key last use", "error", err)
+	}
+
+	c.Set("uid", "api_key:"+apiKey.Api_key_id)
+	logging.SetUID(c.Request.Context(), "api_key:"+apiKey.Api_key_id)
+	c.Set("role", *apiKey.Role)
+	c.Set("api_key_id", apiKey.Api_key_id)
+	c.Set("api_key_scopes", apiKey.Scopes)
+	c.Set("auth_type", "api_key")
+
+	c.Next()
+}
+
+// apiKeyScope returns the resource and action ("read" or "write") the
+// request needs a scope for. The resource is taken from the last static
+// segment of the route that names one, so a nested route such as
+// /api/v1/orders/:order_id/order-items needs the order items scope.
+func apiKeyScope(c *gin.Context) (string, string) {
+	path := strings.TrimPrefix(c.FullPath(), apiVersionPrefix)
+
+	resource := ""
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if scope, ok := apiKeyResources[segment]; ok {
+			resource = scope
+		} else if !strings.HasPrefix(segment, ":") {
+			resource = ""
+		}
+	}
+
+	action := "write"
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		action = "read"
+	}
+
+	return resource, action
+}
@@ -1,23 +1,32 @@
 package middlewares
 
 import (
+	"golang-restaurant-management/apperrors"
 	"golang-restaurant-management/helper"
-	"net/http"
+	"golang-restaurant-management/logging"
+	"golang-restaurant-management/repository"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-type ErrorResponse struct {
-	Error string `json:"error"`
-}
-
-func Authentication() gin.HandlerFunc {
+func Authentication(repos *repository.Repositories) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		clientToken := strings.TrimSpace(c.Request.Header.Get("Authorization"))
+
+		if apiKey := strings.TrimSpace(c.Request.Header.Get("X-API-Key")); apiKey != "" {
+			authenticateApiKey(c, repos.ApiKeys, apiKey)
+			return
+		}
+
+		if strings.HasPrefix(clientToken, "ApiKey ") {
+			authenticateApiKey(c, repos.ApiKeys, strings.TrimSpace(strings.TrimPrefix(clientToken, "ApiKey ")))
+			return
+		}
+
 		if clientToken == "" {
-			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "No token provided"})
+			c.Error(apperrors.Unauthorized("No token provided"))
 			c.Abort()
 			return
 		}
@@ -26,28 +35,49 @@ func Authentication() gin.HandlerFunc {
 			clientToken = strings.TrimPrefix(clientToken, "Bearer ")
 			clientToken = strings.TrimSpace(clientToken)
 		} else {
-			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid token format, expected 'Bearer <token>'"})
+			c.Error(apperrors.Unauthorized("Invalid token format, expected 'Bearer <token>' or 'ApiKey <key>'"))
 			c.Abort()
 			return
 		}
 
 		claims, errMsg := helper.ValidateToken(clientToken)
 		if errMsg != "" {
-			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: errMsg})
+			c.Error(apperrors.Unauthorized(errMsg))
 			c.Abort()
 			return
 		}
 
 		if claims.TokenType != "access" {
-			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid token type, access token required"})
+			c.Error(apperrors.Unauthorized("Invalid token type, access token required"))
+			c.Abort()
+			return
+		}
+
+		revoked, err := helper.IsTokenRevoked(c.Request.Context(), repos, claims)
+		if err != nil {
+			c.Error(apperrors.Internal("Error occurred while checking token", err))
+			c.Abort()
+			return
+		}
+
+		if revoked {
+			c.Error(apperrors.Unauthorized("Token has been revoked"))
 			c.Abort()
 			return
 		}
 
 		c.Set("email", claims.Email)
+		c.Set("email_verified", claims.Email_verified)
+		c.Set("mfa_enabled", claims.Mfa_enabled)
 		c.Set("first_name", claims.First_name)
 		c.Set("last_name", claims.Last_name)
 		c.Set("uid", claims.Uid)
+		logging.SetUID(c.Request.Context(), claims.Uid)
+		c.Set("role", claims.Role)
+		c.Set("session_id", claims.Session_id)
+		c.Set("token_id", claims.Id)
+		c.Set("token_expires_at", claims.ExpiresAt)
+		c.Set("auth_type", "jwt")
 
 		c.Next()
 	}
@@ -0,0 +1,33 @@
+package middlewares
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated marks every response of a group of legacy paths with a
+// Deprecation header. It runs ahead of authentication so that rejected
+// requests are marked too.
+func Deprecated() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Next()
+	}
+}
+
+// Successor links a legacy path to the versioned path replacing it, with the
+// successor's :params filled in from the request.
+func Successor(path string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		segments := strings.Split(path, "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, ":") {
+				segments[i] = c.Param(segment[1:])
+			}
+		}
+
+		c.Header("Link", "<"+strings.Join(segments, "/")+">; rel=\"successor-version\"")
+		c.Next()
+	}
+}
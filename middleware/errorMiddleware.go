@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"golang-restaurant-management/apperrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorHandler writes the response for a handler that returned after
+// c.Error without writing one itself, using the status of the error's kind.
+// The cause of internal errors stays in the request log, not the response.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Writer.Written() || len(c.Errors) == 0 {
+			return
+		}
+
+		appErr := apperrors.As(c.Errors.Last().Err)
+		c.JSON(apperrors.Status(appErr.Kind), appErr.Response())
+	}
+}
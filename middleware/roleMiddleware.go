@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"golang-restaurant-management/apperrors"
+	"golang-restaurant-management/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authorization only lets the request through when the role placed in the
+// context by Authentication() is one of the allowed roles. Managers signed in
+// without two-factor authentication are turned away until they enroll.
+func Authorization(allowedRoles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		if role == "" {
+			c.Error(apperrors.Forbidden("No role assigned to this account"))
+			c.Abort()
+			return
+		}
+
+		for _, allowed := range allowedRoles {
+			if role != allowed {
+				continue
+			}
+			if role == models.RoleManager && c.GetString("auth_type") == "jwt" && !c.GetBool("mfa_enabled") {
+				c.Error(apperrors.Forbidden("Managers have to enable two-factor authentication and sign in again"))
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		c.Error(apperrors.Forbidden("You are not allowed to perform this action"))
+		c.Abort()
+	}
+}
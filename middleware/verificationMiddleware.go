@@ -0,0 +1,22 @@
+package middlewares
+
+import (
+	"golang-restaurant-management/apperrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailVerification rejects access tokens issued to accounts whose e-mail
+// address has not been verified yet. It has to run after Authentication().
+// API keys are not tied to an account and are let through.
+func EmailVerification() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_type") != "api_key" && !c.GetBool("email_verified") {
+			c.Error(apperrors.Forbidden("E-mail address has not been verified"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// resources an API key can be scoped to, as "<resource>:read", "<resource>:write"
+// or "<resource>:*"
+const (
+	ScopeFoods      = "foods"
+	ScopeMenus      = "menus"
+	ScopeTables     = "tables"
+	ScopeOrders     = "orders"
+	ScopeOrderItems = "order_items"
+	ScopeInvoices   = "invoices"
+)
+
+type ApiKey struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	Api_key_id   string             `json:"api_key_id"`
+	Name         *string            `json:"name" validate:"required,min=2,max=100"`
+	Prefix       string             `json:"prefix"`
+	Key_hash     string             `json:"-"`
+	Role         *string            `json:"role" validate:"required,eq=MANAGER|eq=WAITER|eq=CHEF|eq=CASHIER"`
+	Scopes       []string           `json:"scopes" validate:"required,min=1,dive,required"`
+	Created_by   string             `json:"created_by"`
+	Created_at   time.Time          `json:"created_at"`
+	Expires_at   *time.Time         `json:"expires_at"`
+	Last_used_at *time.Time         `json:"last_used_at"`
+	Revoked_at   *time.Time         `json:"revoked_at"`
+}
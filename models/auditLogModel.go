@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuditLog struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Audit_id   string             `json:"audit_id"`
+	Actor_id   string             `json:"actor_id"`
+	Actor_role string             `json:"actor_role"`
+	Method     string             `json:"method"`
+	Route      string             `json:"route"`
+	Entity     string             `json:"entity"`
+	Entity_id  string             `json:"entity_id"`
+	Before     bson.M             `json:"before" swaggertype:"object"`
+	After      bson.M             `json:"after" swaggertype:"object"`
+	Ip_address string             `json:"ip_address"`
+	Created_at time.Time          `json:"created_at"`
+}
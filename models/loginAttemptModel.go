@@ -0,0 +1,12 @@
+package models
+
+import (
+	"time"
+)
+
+type LoginAttempt struct {
+	Key            string     `json:"key"`
+	Failed_count   int        `json:"failed_count"`
+	Last_failed_at time.Time  `json:"last_failed_at"`
+	Locked_until   *time.Time `json:"locked_until"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OneTimeToken backs single-use links such as password resets and e-mail
+// verification. Only the hash of the token is stored, next to the address
+// it was mailed to.
+type OneTimeToken struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	User_id    string             `json:"user_id"`
+	Email      string             `json:"email"`
+	Token_hash string             `json:"-"`
+	Expires_at time.Time          `json:"expires_at"`
+	Used_at    *time.Time         `json:"used_at"`
+	Created_at time.Time          `json:"created_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RevokedToken struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Token_id   string             `json:"token_id"`
+	User_id    string             `json:"user_id"`
+	Expires_at time.Time          `json:"expires_at"`
+	Revoked_at time.Time          `json:"revoked_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	SecurityEventRefreshTokenReuse = "REFRESH_TOKEN_REUSE"
+)
+
+type SecurityEvent struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Event_id   string             `json:"event_id"`
+	Type       string             `json:"type"`
+	User_id    string             `json:"user_id"`
+	Session_id string             `json:"session_id"`
+	Ip_address string             `json:"ip_address"`
+	User_agent string             `json:"user_agent"`
+	Details    string             `json:"details"`
+	Created_at time.Time          `json:"created_at"`
+}
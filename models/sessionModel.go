@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Session struct {
+	ID                 primitive.ObjectID `bson:"_id"`
+	Session_id         string             `json:"session_id"`
+	User_id            string             `json:"user_id"`
+	Device_name        string             `json:"device_name"`
+	Ip_address         string             `json:"ip_address"`
+	User_agent         string             `json:"user_agent"`
+	Refresh_token_hash string             `json:"-"`
+	Created_at         time.Time          `json:"created_at"`
+	Last_used_at       time.Time          `json:"last_used_at"`
+	Revoked_at         *time.Time         `json:"revoked_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Terminal is a shared POS device that staff can log in on with their PIN.
+type Terminal struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	Terminal_id  string             `json:"terminal_id"`
+	Name         *string            `json:"name" validate:"required,min=2,max=100"`
+	Token_hash   string             `json:"-"`
+	Created_by   string             `json:"created_by"`
+	Created_at   time.Time          `json:"created_at"`
+	Last_used_at *time.Time         `json:"last_used_at"`
+	Revoked_at   *time.Time         `json:"revoked_at"`
+}
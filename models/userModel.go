@@ -5,17 +5,36 @@ import (
 	"time"
 )
 
+const (
+	RoleAdmin   = "ADMIN"
+	RoleManager = "MANAGER"
+	RoleWaiter  = "WAITER"
+	RoleChef    = "CHEF"
+	RoleCashier = "CASHIER"
+)
+
 type User struct {
-	ID            primitive.ObjectID `bson:"_id"`
-	First_name    *string            `json:"first_name" validate:"required,min=2,max=100"`
-	Last_name     *string            `json:"last_name" validate:"required,min=2,max=100"`
-	Password      *string            `json:"password" validate:"required,min=6"`
-	Email         *string            `json:"email" validate:"email,required"`
-	Avatar        *string            `json:"avatar"`
-	Phone         *string            `json:"phone" validate:"required"`
-	Token         *string            `json:"token"`
-	Refresh_token *string            `json:"refresh_token"`
-	Created_at    time.Time          `json:"created_at"`
-	Updated_at    time.Time          `json:"updated_at"`
-	User_id       string             `json:"user_id"`
+	ID                 primitive.ObjectID `bson:"_id"`
+	First_name         *string            `json:"first_name" validate:"required,min=2,max=100"`
+	Last_name          *string            `json:"last_name" validate:"required,min=2,max=100"`
+	Password           *string            `json:"password" validate:"required,min=6"`
+	Email              *string            `json:"email" validate:"email,required"`
+	Avatar             *string            `json:"avatar"`
+	Phone              *string            `json:"phone" validate:"required"`
+	Role               *string            `json:"role" validate:"omitempty,eq=ADMIN|eq=MANAGER|eq=WAITER|eq=CHEF|eq=CASHIER"`
+	Email_verified     *bool              `json:"email_verified"`
+	Email_verified_at  *time.Time         `json:"email_verified_at"`
+	Mfa_enabled        *bool              `json:"mfa_enabled"`
+	Mfa_secret         *string            `json:"-"`
+	Mfa_pending_secret *string            `json:"-"`
+	Mfa_recovery_codes []string           `json:"-"`
+	Mfa_last_step      int64              `json:"-"`
+	Pin                *string            `json:"-"`
+	Token              *string            `json:"token"`
+	Refresh_token      *string            `json:"refresh_token"`
+	Tokens_revoked_at  *time.Time         `json:"tokens_revoked_at"`
+	Deactivated_at     *time.Time         `json:"deactivated_at"`
+	Created_at         time.Time          `json:"created_at"`
+	Updated_at         time.Time          `json:"updated_at"`
+	User_id            string             `json:"user_id"`
 }
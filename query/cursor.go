@@ -0,0 +1,198 @@
+package query
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidCursor is returned for a cursor that was not issued by this API or
+// was issued for a different sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursor records where a page ended: the sort values and _id of its last
+// item. _id breaks ties, so pages never skip or repeat items with equal sort
+// values.
+type cursor struct {
+	Sort   string             `bson:"s"`
+	Values bson.A             `bson:"v"`
+	ID     primitive.ObjectID `bson:"id"`
+}
+
+func sortKey(sort []SortField) string {
+	parts := make([]string, 0, len(sort))
+	for _, field := range sort {
+		if field.Desc {
+			parts = append(parts, "-"+field.Field)
+		} else {
+			parts = append(parts, field.Field)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// encodeCursor builds the cursor for the page ending at doc, an item in its
+// BSON form.
+func encodeCursor(sort []SortField, doc bson.M) (string, error) {
+	c := cursor{Sort: sortKey(sort)}
+	for _, field := range sort {
+		c.Values = append(c.Values, doc[field.Field])
+	}
+	c.ID, _ = doc["_id"].(primitive.ObjectID)
+
+	data, err := bson.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(value string, sort []SortField) (cursor, error) {
+	var c cursor
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := bson.Unmarshal(data, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	if c.Sort != sortKey(sort) || len(c.Values) != len(sort) {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// toDoc gives the BSON form of an item, with the field names filters and
+// sorts refer to.
+func toDoc(item interface{}) (bson.M, error) {
+	data, err := bson.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc bson.M
+	err = bson.Unmarshal(data, &doc)
+	return doc, err
+}
+
+// compare orders two BSON values the way MongoDB does for the types the
+// models use: null first, then numbers, strings, object IDs and dates.
+func compare(a, b interface{}) int {
+	rankA, rankB := typeRank(a), typeRank(b)
+	if rankA != rankB {
+		return rankA - rankB
+	}
+
+	switch x := normalize(a).(type) {
+	case float64:
+		y := normalize(b).(float64)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	case string:
+		return strings.Compare(x, normalize(b).(string))
+	case primitive.ObjectID:
+		y := normalize(b).(primitive.ObjectID)
+		return bytes.Compare(x[:], y[:])
+	case primitive.DateTime:
+		y := normalize(b).(primitive.DateTime)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	case bool:
+		y := normalize(b).(bool)
+		switch {
+		case !x && y:
+			return -1
+		case x && !y:
+			return 1
+		}
+	}
+	return 0
+}
+
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case time.Time:
+		return primitive.NewDateTimeFromTime(v)
+	}
+	return value
+}
+
+func typeRank(value interface{}) int {
+	switch normalize(value).(type) {
+	case nil:
+		return 0
+	case float64:
+		return 1
+	case string:
+		return 2
+	case primitive.ObjectID:
+		return 3
+	case bool:
+		return 4
+	case primitive.DateTime:
+		return 5
+	}
+	return 6
+}
+
+// withTieBreak appends _id to the sort, in the direction of the last field,
+// so that every item has a distinct position.
+func withTieBreak(sort []SortField) []SortField {
+	desc := len(sort) > 0 && sort[len(sort)-1].Desc
+
+	fields := append([]SortField{}, sort...)
+	return append(fields, SortField{Field: "_id", Desc: desc})
+}
+
+// cursorDoc rebuilds the sort values of the item a cursor was issued for.
+func cursorDoc(sort []SortField, c cursor) bson.M {
+	doc := bson.M{"_id": c.ID}
+	for i, field := range sort {
+		doc[field.Field] = c.Values[i]
+	}
+	return doc
+}
+
+// newPage trims the one extra item the page was read with and, when there
+// was one, issues the cursor for the next page.
+func newPage[T any](items []T, total int64, params Params) (Page[T], error) {
+	page := Page[T]{Items: items, Total: total, Limit: params.Limit}
+	if params.Cursor == "" {
+		page.Offset = params.Offset
+	}
+
+	if params.Limit <= 0 || int64(len(items)) <= params.Limit {
+		return page, nil
+	}
+
+	page.Items = items[:params.Limit]
+
+	last, err := toDoc(page.Items[len(page.Items)-1])
+	if err != nil {
+		return Page[T]{}, err
+	}
+	if page.NextCursor, err = encodeCursor(params.Sort, last); err != nil {
+		return Page[T]{}, err
+	}
+	return page, nil
+}
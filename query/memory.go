@@ -0,0 +1,108 @@
+package query
+
+import (
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Apply runs a list request over items held in memory, with the same results
+// Find gives for a collection holding them.
+func Apply[T any](items []T, params Params) (Page[T], error) {
+	docs := make([]bson.M, len(items))
+	for i, item := range items {
+		doc, err := toDoc(item)
+		if err != nil {
+			return Page[T]{}, err
+		}
+		docs[i] = doc
+	}
+
+	var indexes []int
+	for i, doc := range docs {
+		if matches(doc, params.Filters) {
+			indexes = append(indexes, i)
+		}
+	}
+	total := int64(len(indexes))
+
+	fields := withTieBreak(params.Sort)
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return compareDocs(fields, docs[indexes[i]], docs[indexes[j]]) < 0
+	})
+
+	if params.Cursor != "" {
+		c, err := decodeCursor(params.Cursor, params.Sort)
+		if err != nil {
+			return Page[T]{}, err
+		}
+		last := cursorDoc(params.Sort, c)
+
+		after := indexes[:0]
+		for _, index := range indexes {
+			if compareDocs(fields, docs[index], last) > 0 {
+				after = append(after, index)
+			}
+		}
+		indexes = after
+	} else if params.Offset >= int64(len(indexes)) {
+		indexes = nil
+	} else {
+		indexes = indexes[params.Offset:]
+	}
+
+	if params.Limit > 0 && int64(len(indexes)) > params.Limit+1 {
+		indexes = indexes[:params.Limit+1]
+	}
+
+	page := make([]T, 0, len(indexes))
+	for _, index := range indexes {
+		page = append(page, items[index])
+	}
+
+	return newPage(page, total, params)
+}
+
+func matches(doc bson.M, filters []Filter) bool {
+	for _, filter := range filters {
+		// as in MongoDB, values of another type never match a comparison
+		value := doc[filter.Field]
+		if typeRank(value) != typeRank(filter.Value) {
+			return false
+		}
+
+		order := compare(value, filter.Value)
+
+		switch filter.Op {
+		case Eq:
+			if order != 0 {
+				return false
+			}
+		case Gte:
+			if order < 0 {
+				return false
+			}
+		case Lte:
+			if order > 0 {
+				return false
+			}
+		case Lt:
+			if order >= 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func compareDocs(fields []SortField, a, b bson.M) int {
+	for _, field := range fields {
+		if order := compare(a[field.Field], b[field.Field]); order != 0 {
+			if field.Desc {
+				return -order
+			}
+			return order
+		}
+	}
+	return 0
+}
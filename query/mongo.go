@@ -0,0 +1,99 @@
+package query
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Find runs a list request against a collection. base holds the conditions
+// the caller always applies, on top of the request's filters. Offset pages
+// use skip; cursor pages start right after the cursor's sort values, so they
+// stay cheap however deep the client pages.
+func Find[T any](ctx context.Context, collection *mongo.Collection, base bson.M, params Params) (Page[T], error) {
+	filter := mongoFilter(base, params.Filters)
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return Page[T]{}, err
+	}
+
+	sort := withTieBreak(params.Sort)
+	opts := options.Find().SetSort(mongoSort(sort))
+
+	if params.Cursor != "" {
+		c, err := decodeCursor(params.Cursor, params.Sort)
+		if err != nil {
+			return Page[T]{}, err
+		}
+		filter = bson.M{"$and": bson.A{filter, afterCursor(sort, cursorDoc(params.Sort, c))}}
+	} else {
+		opts.SetSkip(params.Offset)
+	}
+
+	if params.Limit > 0 {
+		opts.SetLimit(params.Limit + 1)
+	}
+
+	result, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return Page[T]{}, err
+	}
+
+	items := []T{}
+	if err := result.All(ctx, &items); err != nil {
+		return Page[T]{}, err
+	}
+
+	return newPage(items, total, params)
+}
+
+func mongoFilter(base bson.M, filters []Filter) bson.M {
+	conditions := bson.A{}
+	if len(base) > 0 {
+		conditions = append(conditions, base)
+	}
+	for _, filter := range filters {
+		conditions = append(conditions, bson.M{filter.Field: bson.M{"$" + string(filter.Op): filter.Value}})
+	}
+
+	if len(conditions) == 0 {
+		return bson.M{}
+	}
+	return bson.M{"$and": conditions}
+}
+
+func mongoSort(sort []SortField) bson.D {
+	doc := bson.D{}
+	for _, field := range sort {
+		direction := 1
+		if field.Desc {
+			direction = -1
+		}
+		doc = append(doc, bson.E{Key: field.Field, Value: direction})
+	}
+	return doc
+}
+
+// afterCursor matches the items that sort after last: those equal to it on
+// the first i sort fields and past it on field i, for any i.
+func afterCursor(sort []SortField, last bson.M) bson.M {
+	alternatives := bson.A{}
+	for i, field := range sort {
+		condition := bson.M{}
+		for _, previous := range sort[:i] {
+			condition[previous.Field] = last[previous.Field]
+		}
+
+		op := "$gt"
+		if field.Desc {
+			op = "$lt"
+		}
+		condition[field.Field] = bson.M{op: last[field.Field]}
+
+		alternatives = append(alternatives, condition)
+	}
+	return bson.M{"$or": alternatives}
+}
@@ -0,0 +1,304 @@
+package query
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 500
+)
+
+// FieldType decides which query parameters a filter accepts. A String filter
+// matches <param> exactly; a Number filter matches <param> exactly or a range
+// with <param>_from and <param>_to; a Time filter only takes the range.
+type FieldType int
+
+const (
+	String FieldType = iota
+	Number
+	Time
+)
+
+// FilterSpec exposes the stored Field as the query parameter Param.
+type FilterSpec struct {
+	Param string
+	Field string
+	Type  FieldType
+}
+
+// Spec describes what a list endpoint can be filtered and sorted by. Sorts
+// lists the stored field names accepted by sort=, and DefaultSort is used
+// when there is none, in the same "field,-field" form. Zero limits fall back
+// to 20 items per page and at most 500.
+type Spec struct {
+	Filters      []FilterSpec
+	Sorts        []string
+	DefaultSort  string
+	DefaultLimit int64
+	MaxLimit     int64
+}
+
+// Op is the comparison a Filter makes.
+type Op string
+
+const (
+	Eq  Op = "eq"
+	Gte Op = "gte"
+	Lte Op = "lte"
+	Lt  Op = "lt"
+)
+
+type Filter struct {
+	Field string
+	Op    Op
+	Value interface{}
+}
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Params is a parsed list request. A zero Limit means no limit, which only
+// internal callers use. When Cursor is set the page starts after the item
+// the cursor was issued for and Offset is ignored.
+type Params struct {
+	Filters []Filter
+	Sort    []SortField
+	Limit   int64
+	Offset  int64
+	Cursor  string
+}
+
+// Page is the envelope every list endpoint responds with. Total counts the
+// items matching the filters, across all pages. NextCursor is set when there
+// are more items after this page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int64  `json:"total"`
+	Limit      int64  `json:"limit"`
+	Offset     int64  `json:"offset"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// MapPage converts the items of a page, keeping the pagination fields.
+func MapPage[T, U any](page Page[T], convert func(T) U) Page[U] {
+	items := make([]U, 0, len(page.Items))
+	for _, item := range page.Items {
+		items = append(items, convert(item))
+	}
+
+	return Page[U]{
+		Items:      items,
+		Total:      page.Total,
+		Limit:      page.Limit,
+		Offset:     page.Offset,
+		NextCursor: page.NextCursor,
+	}
+}
+
+// Whole wraps a list that is never paginated in a Page, so it responds with
+// the same envelope as the paginated ones. The zero Limit means no limit.
+func Whole[T any](items []T) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return Page[T]{Items: items, Total: int64(len(items))}
+}
+
+// Parse reads limit, offset or page, cursor, sort and the filters of the spec
+// from the query string. recordPerPage and startIndex are still accepted for
+// limit and offset. Unknown sort fields, malformed values and conflicting
+// paging parameters are errors, so a typo does not silently return
+// everything.
+func Parse(values url.Values, spec Spec) (Params, error) {
+	params := Params{Limit: spec.DefaultLimit}
+	if params.Limit == 0 {
+		params.Limit = defaultLimit
+	}
+
+	limitCap := spec.MaxLimit
+	if limitCap == 0 {
+		limitCap = maxLimit
+	}
+
+	limit, err := intParam(values, "limit", "recordPerPage")
+	if err != nil {
+		return Params{}, err
+	}
+	if limit != nil {
+		if *limit < 1 || *limit > limitCap {
+			return Params{}, fmt.Errorf("limit must be between 1 and %d", limitCap)
+		}
+		params.Limit = *limit
+	}
+
+	offset, err := intParam(values, "offset", "startIndex")
+	if err != nil {
+		return Params{}, err
+	}
+	page, err := intParam(values, "page")
+	if err != nil {
+		return Params{}, err
+	}
+
+	if offset != nil && page != nil {
+		return Params{}, fmt.Errorf("offset and page cannot be combined")
+	}
+
+	switch {
+	case offset != nil:
+		if *offset < 0 {
+			return Params{}, fmt.Errorf("offset must not be negative")
+		}
+		params.Offset = *offset
+	case page != nil:
+		if *page < 1 {
+			return Params{}, fmt.Errorf("page must be at least 1")
+		}
+		params.Offset = (*page - 1) * params.Limit
+	}
+
+	params.Cursor = values.Get("cursor")
+	if params.Cursor != "" && (offset != nil || page != nil) {
+		return Params{}, fmt.Errorf("cursor cannot be combined with offset or page")
+	}
+
+	sort := values.Get("sort")
+	if sort == "" {
+		sort = spec.DefaultSort
+	}
+	if params.Sort, err = parseSort(sort, spec.Sorts); err != nil {
+		return Params{}, err
+	}
+
+	if params.Cursor != "" {
+		if _, err := decodeCursor(params.Cursor, params.Sort); err != nil {
+			return Params{}, fmt.Errorf("cursor is not valid for this list and sort order: %w", err)
+		}
+	}
+
+	for _, filter := range spec.Filters {
+		filters, err := parseFilter(values, filter)
+		if err != nil {
+			return Params{}, err
+		}
+		params.Filters = append(params.Filters, filters...)
+	}
+
+	return params, nil
+}
+
+func intParam(values url.Values, names ...string) (*int64, error) {
+	for _, name := range names {
+		value := values.Get(name)
+		if value == "" {
+			continue
+		}
+
+		number, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a whole number", name)
+		}
+		return &number, nil
+	}
+	return nil, nil
+}
+
+func parseSort(sort string, allowed []string) ([]SortField, error) {
+	var fields []SortField
+	for _, part := range strings.Split(sort, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		field := SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if !contains(allowed, field.Field) {
+			return nil, fmt.Errorf("cannot sort by %s; sortable fields are %s", field.Field, strings.Join(allowed, ", "))
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func parseFilter(values url.Values, spec FilterSpec) ([]Filter, error) {
+	var filters []Filter
+
+	if spec.Type != Time {
+		if value := values.Get(spec.Param); value != "" {
+			parsed, err := parseValue(spec, spec.Param, value, false)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, Filter{Field: spec.Field, Op: Eq, Value: parsed})
+		}
+	}
+
+	if spec.Type == String {
+		return filters, nil
+	}
+
+	if value := values.Get(spec.Param + "_from"); value != "" {
+		parsed, err := parseValue(spec, spec.Param+"_from", value, false)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, Filter{Field: spec.Field, Op: Gte, Value: parsed})
+	}
+
+	if value := values.Get(spec.Param + "_to"); value != "" {
+		parsed, err := parseValue(spec, spec.Param+"_to", value, true)
+		if err != nil {
+			return nil, err
+		}
+
+		// a bare date as the upper bound includes that whole day
+		op := Lte
+		if spec.Type == Time && len(value) == len(time.DateOnly) {
+			op = Lt
+		}
+		filters = append(filters, Filter{Field: spec.Field, Op: op, Value: parsed})
+	}
+
+	return filters, nil
+}
+
+func parseValue(spec FilterSpec, param, value string, upper bool) (interface{}, error) {
+	switch spec.Type {
+	case Number:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", param)
+		}
+		return number, nil
+	case Time:
+		if date, err := time.Parse(time.DateOnly, value); err == nil {
+			if upper {
+				date = date.AddDate(0, 0, 1)
+			}
+			return date, nil
+		}
+		timestamp, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a date (2006-01-02) or an RFC 3339 timestamp", param)
+		}
+		return timestamp, nil
+	default:
+		return value, nil
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
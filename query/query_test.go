@@ -0,0 +1,271 @@
+package query
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type testItem struct {
+	ID    primitive.ObjectID `bson:"_id"`
+	Name  string             `bson:"name"`
+	Price float64            `bson:"price"`
+}
+
+var testSpec = Spec{
+	Filters: []FilterSpec{
+		{Param: "name", Field: "name", Type: String},
+		{Param: "price", Field: "price", Type: Number},
+		{Param: "created_at", Field: "created_at", Type: Time},
+	},
+	Sorts:       []string{"name", "price"},
+	DefaultSort: "name",
+}
+
+// objectID returns an ObjectID that sorts by n.
+func objectID(n int) primitive.ObjectID {
+	var id primitive.ObjectID
+	id[len(id)-1] = byte(n)
+	return id
+}
+
+func TestParse(t *testing.T) {
+	validCursor, err := encodeCursor([]SortField{{Field: "price", Desc: true}}, map[string]interface{}{"price": 9.5, "_id": primitive.NewObjectID()})
+	if err != nil {
+		t.Fatalf("encodeCursor: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		want    Params
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			want:  Params{Limit: defaultLimit, Sort: []SortField{{Field: "name"}}},
+		},
+		{
+			name:  "limit and page",
+			query: "limit=5&page=3",
+			want:  Params{Limit: 5, Offset: 10, Sort: []SortField{{Field: "name"}}},
+		},
+		{
+			name:  "legacy limit and offset names",
+			query: "recordPerPage=7&startIndex=14",
+			want:  Params{Limit: 7, Offset: 14, Sort: []SortField{{Field: "name"}}},
+		},
+		{
+			name:  "sort and number filters",
+			query: "sort=-price,name&price_from=2&price_to=10",
+			want: Params{
+				Limit: defaultLimit,
+				Sort:  []SortField{{Field: "price", Desc: true}, {Field: "name"}},
+				Filters: []Filter{
+					{Field: "price", Op: Gte, Value: 2.0},
+					{Field: "price", Op: Lte, Value: 10.0},
+				},
+			},
+		},
+		{
+			name:  "bare date upper bound includes the whole day",
+			query: "created_at_to=2024-05-01",
+			want: Params{
+				Limit:   defaultLimit,
+				Sort:    []SortField{{Field: "name"}},
+				Filters: []Filter{{Field: "created_at", Op: Lt, Value: time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)}},
+			},
+		},
+		{
+			name:  "cursor issued for the same sort",
+			query: "sort=-price&cursor=" + validCursor,
+			want:  Params{Limit: defaultLimit, Sort: []SortField{{Field: "price", Desc: true}}, Cursor: validCursor},
+		},
+		{name: "limit not a number", query: "limit=ten", wantErr: true},
+		{name: "limit zero", query: "limit=0", wantErr: true},
+		{name: "limit above the cap", query: "limit=501", wantErr: true},
+		{name: "negative offset", query: "offset=-1", wantErr: true},
+		{name: "page zero", query: "page=0", wantErr: true},
+		{name: "page plus offset", query: "page=2&offset=10", wantErr: true},
+		{name: "cursor plus page", query: "page=2&cursor=" + validCursor, wantErr: true},
+		{name: "unknown sort", query: "sort=colour", wantErr: true},
+		{name: "bad number filter", query: "price=cheap", wantErr: true},
+		{name: "bad date filter", query: "created_at_from=yesterday", wantErr: true},
+		{name: "cursor not base64", query: "cursor=%21%21%21", wantErr: true},
+		{name: "cursor for another sort", query: "sort=name&cursor=" + validCursor, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParseQuery: %v", err)
+			}
+
+			got, err := Parse(values, testSpec)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse(%q) = %+v, want an error", tt.query, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.query, err)
+			}
+
+			if got.Limit != tt.want.Limit || got.Offset != tt.want.Offset || got.Cursor != tt.want.Cursor {
+				t.Errorf("Parse(%q) paging = limit %d offset %d cursor %q, want limit %d offset %d cursor %q",
+					tt.query, got.Limit, got.Offset, got.Cursor, tt.want.Limit, tt.want.Offset, tt.want.Cursor)
+			}
+			if len(got.Sort) != len(tt.want.Sort) {
+				t.Fatalf("Parse(%q) sort = %+v, want %+v", tt.query, got.Sort, tt.want.Sort)
+			}
+			for i := range got.Sort {
+				if got.Sort[i] != tt.want.Sort[i] {
+					t.Errorf("Parse(%q) sort = %+v, want %+v", tt.query, got.Sort, tt.want.Sort)
+				}
+			}
+			if len(got.Filters) != len(tt.want.Filters) {
+				t.Fatalf("Parse(%q) filters = %+v, want %+v", tt.query, got.Filters, tt.want.Filters)
+			}
+			for i := range got.Filters {
+				if got.Filters[i].Field != tt.want.Filters[i].Field || got.Filters[i].Op != tt.want.Filters[i].Op || compare(got.Filters[i].Value, tt.want.Filters[i].Value) != 0 {
+					t.Errorf("Parse(%q) filters = %+v, want %+v", tt.query, got.Filters, tt.want.Filters)
+				}
+			}
+		})
+	}
+}
+
+func TestParseBadCursorIsInvalidCursor(t *testing.T) {
+	_, err := Parse(url.Values{"cursor": {"bm90LWEtY3Vyc29y"}}, testSpec)
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("Parse with a bad cursor: got %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestApplyOffsetPaging(t *testing.T) {
+	items := []testItem{
+		{ID: objectID(1), Name: "soup", Price: 4},
+		{ID: objectID(2), Name: "bread", Price: 2},
+		{ID: objectID(3), Name: "steak", Price: 20},
+		{ID: objectID(4), Name: "salad", Price: 6},
+		{ID: objectID(5), Name: "cake", Price: 5},
+	}
+
+	params := Params{
+		Sort:    []SortField{{Field: "price"}},
+		Filters: []Filter{{Field: "price", Op: Gte, Value: 3.0}},
+		Limit:   2,
+		Offset:  1,
+	}
+
+	page, err := Apply(items, params)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	if page.Total != 4 {
+		t.Errorf("Total = %d, want the 4 items priced 3 or more", page.Total)
+	}
+	if page.Limit != 2 || page.Offset != 1 {
+		t.Errorf("Limit, Offset = %d, %d, want 2, 1", page.Limit, page.Offset)
+	}
+
+	want := []string{"cake", "salad"}
+	if len(page.Items) != len(want) {
+		t.Fatalf("Items = %+v, want %v", page.Items, want)
+	}
+	for i, name := range want {
+		if page.Items[i].Name != name {
+			t.Errorf("Items[%d] = %s, want %s", i, page.Items[i].Name, name)
+		}
+	}
+	if page.NextCursor == "" {
+		t.Errorf("NextCursor is empty although steak is still left")
+	}
+
+	page, err = Apply(items, Params{Sort: params.Sort, Filters: params.Filters, Limit: 2, Offset: 10})
+	if err != nil {
+		t.Fatalf("Apply past the end: %v", err)
+	}
+	if len(page.Items) != 0 || page.NextCursor != "" || page.Total != 4 {
+		t.Errorf("page past the end = %+v, want no items, no cursor and total 4", page)
+	}
+}
+
+func TestApplyCursorPagingAcrossEqualSortKeys(t *testing.T) {
+	// every item has the same price, so only the _id tie-break keeps pages
+	// from repeating or skipping items
+	var items []testItem
+	for n := 7; n >= 1; n-- {
+		items = append(items, testItem{ID: objectID(n), Name: "dish", Price: 5})
+	}
+
+	sort := []SortField{{Field: "price", Desc: true}}
+
+	var seen []primitive.ObjectID
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > len(items) {
+			t.Fatalf("cursor paging did not terminate, saw %v", seen)
+		}
+
+		values := url.Values{"limit": {"3"}, "sort": {"-price"}}
+		if cursor != "" {
+			values.Set("cursor", cursor)
+		}
+		params, err := Parse(values, Spec{Sorts: []string{"price"}})
+		if err != nil {
+			t.Fatalf("Parse page %d: %v", pages, err)
+		}
+		if len(params.Sort) != 1 || params.Sort[0] != sort[0] {
+			t.Fatalf("Parse page %d sort = %+v, want %+v", pages, params.Sort, sort)
+		}
+
+		page, err := Apply(items, params)
+		if err != nil {
+			t.Fatalf("Apply page %d: %v", pages, err)
+		}
+		if page.Total != int64(len(items)) {
+			t.Errorf("page %d Total = %d, want %d", pages, page.Total, len(items))
+		}
+
+		for _, item := range page.Items {
+			seen = append(seen, item.ID)
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	if len(seen) != len(items) {
+		t.Fatalf("saw %d items over all pages, want %d: %v", len(seen), len(items), seen)
+	}
+	for i, id := range seen {
+		// descending sort, so the _id tie-break is descending too
+		if want := objectID(len(items) - i); id != want {
+			t.Errorf("item %d = %s, want %s", i, id.Hex(), want.Hex())
+		}
+	}
+}
+
+func TestApplyRejectsCursorForAnotherSort(t *testing.T) {
+	items := []testItem{{ID: objectID(1), Name: "soup", Price: 4}, {ID: objectID(2), Name: "bread", Price: 2}}
+
+	page, err := Apply(items, Params{Sort: []SortField{{Field: "price"}}, Limit: 1})
+	if err != nil || page.NextCursor == "" {
+		t.Fatalf("first page = %+v, %v, want a next cursor", page, err)
+	}
+
+	_, err = Apply(items, Params{Sort: []SortField{{Field: "name"}}, Limit: 1, Cursor: page.NextCursor})
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("Apply with a cursor for another sort: got %v, want ErrInvalidCursor", err)
+	}
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"golang-restaurant-management/models"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ApiKeyRepository interface {
+	Create(ctx context.Context, apiKey models.ApiKey) error
+	FindByID(ctx context.Context, apiKeyId string) (models.ApiKey, error)
+	// ListActive returns the keys that have not been revoked, newest first.
+	ListActive(ctx context.Context) ([]models.ApiKey, error)
+	// FindActiveByHash returns the unrevoked key whose hash is keyHash.
+	FindActiveByHash(ctx context.Context, keyHash string) (models.ApiKey, error)
+	// Revoke reports false when there is no active key with this id.
+	Revoke(ctx context.Context, apiKeyId string, at time.Time) (bool, error)
+	// Touch sets last_used_at to at unless it was already set less than
+	// interval before.
+	Touch(ctx context.Context, apiKeyId string, at time.Time, interval time.Duration) error
+}
+
+type mongoApiKeyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoApiKeyRepository(db *mongo.Database) ApiKeyRepository {
+	return &mongoApiKeyRepository{collection: db.Collection(apiKeyCollectionName)}
+}
+
+func (r *mongoApiKeyRepository) Create(ctx context.Context, apiKey models.ApiKey) error {
+	_, err := r.collection.InsertOne(ctx, apiKey)
+	return err
+}
+
+func (r *mongoApiKeyRepository) FindByID(ctx context.Context, apiKeyId string) (models.ApiKey, error) {
+	var apiKey models.ApiKey
+	err := findOne(ctx, r.collection, bson.M{"api_key_id": apiKeyId}, &apiKey)
+	return apiKey, err
+}
+
+func (r *mongoApiKeyRepository) ListActive(ctx context.Context) ([]models.ApiKey, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"revoked_at": nil}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys := []models.ApiKey{}
+	if err = cursor.All(ctx, &apiKeys); err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+func (r *mongoApiKeyRepository) FindActiveByHash(ctx context.Context, keyHash string) (models.ApiKey, error) {
+	var apiKey models.ApiKey
+	err := findOne(ctx, r.collection, bson.M{"key_hash": keyHash, "revoked_at": nil}, &apiKey)
+	return apiKey, err
+}
+
+func (r *mongoApiKeyRepository) Revoke(ctx context.Context, apiKeyId string, at time.Time) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"api_key_id": apiKeyId, "revoked_at": nil},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "revoked_at", Value: at}}}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (r *mongoApiKeyRepository) Touch(ctx context.Context, apiKeyId string, at time.Time, interval time.Duration) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{
+			"api_key_id": apiKeyId,
+			"$or": bson.A{
+				bson.M{"last_used_at": nil},
+				bson.M{"last_used_at": bson.M{"$lt": at.Add(-interval)}},
+			},
+		},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "last_used_at", Value: at}}}},
+	)
+	return err
+}
+
+type memoryApiKeyRepository struct {
+	store *memoryStore[models.ApiKey]
+}
+
+func NewMemoryApiKeyRepository() ApiKeyRepository {
+	return &memoryApiKeyRepository{store: newMemoryStore[models.ApiKey]("api_key_id")}
+}
+
+func (r *memoryApiKeyRepository) Create(ctx context.Context, apiKey models.ApiKey) error {
+	r.store.insert(apiKey.Api_key_id, apiKey)
+	return nil
+}
+
+func (r *memoryApiKeyRepository) FindByID(ctx context.Context, apiKeyId string) (models.ApiKey, error) {
+	return r.store.find(apiKeyId)
+}
+
+func (r *memoryApiKeyRepository) ListActive(ctx context.Context) ([]models.ApiKey, error) {
+	apiKeys := []models.ApiKey{}
+	for _, apiKey := range r.store.all() {
+		if apiKey.Revoked_at == nil {
+			apiKeys = append(apiKeys, apiKey)
+		}
+	}
+
+	sort.SliceStable(apiKeys, func(i, j int) bool {
+		return apiKeys[i].Created_at.After(apiKeys[j].Created_at)
+	})
+	return apiKeys, nil
+}
+
+func (r *memoryApiKeyRepository) FindActiveByHash(ctx context.Context, keyHash string) (models.ApiKey, error) {
+	for _, apiKey := range r.store.all() {
+		if apiKey.Key_hash == keyHash && apiKey.Revoked_at == nil {
+			return apiKey, nil
+		}
+	}
+	return models.ApiKey{}, ErrNotFound
+}
+
+func (r *memoryApiKeyRepository) Revoke(ctx context.Context, apiKeyId string, at time.Time) (bool, error) {
+	return r.store.modify(apiKeyId, func(apiKey *models.ApiKey) (bool, error) {
+		if apiKey.Revoked_at != nil {
+			return false, nil
+		}
+		apiKey.Revoked_at = &at
+		return true, nil
+	})
+}
+
+func (r *memoryApiKeyRepository) Touch(ctx context.Context, apiKeyId string, at time.Time, interval time.Duration) error {
+	_, err := r.store.modify(apiKeyId, func(apiKey *models.ApiKey) (bool, error) {
+		if apiKey.Last_used_at != nil && !apiKey.Last_used_at.Before(at.Add(-interval)) {
+			return false, nil
+		}
+		apiKey.Last_used_at = &at
+		return true, nil
+	})
+	return err
+}
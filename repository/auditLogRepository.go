@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"golang-restaurant-management/models"
+	"golang-restaurant-management/query"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type AuditLogRepository interface {
+	Create(ctx context.Context, entry models.AuditLog) error
+	// List returns the page of audit entries the params select.
+	List(ctx context.Context, params query.Params) (query.Page[models.AuditLog], error)
+}
+
+type mongoAuditLogRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoAuditLogRepository(db *mongo.Database) AuditLogRepository {
+	return &mongoAuditLogRepository{collection: db.Collection(auditLogCollectionName)}
+}
+
+func (r *mongoAuditLogRepository) Create(ctx context.Context, entry models.AuditLog) error {
+	_, err := r.collection.InsertOne(ctx, entry)
+	return err
+}
+
+func (r *mongoAuditLogRepository) List(ctx context.Context, params query.Params) (query.Page[models.AuditLog], error) {
+	return query.Find[models.AuditLog](ctx, r.collection, bson.M{}, params)
+}
+
+type memoryAuditLogRepository struct {
+	store *memoryStore[models.AuditLog]
+}
+
+func NewMemoryAuditLogRepository() AuditLogRepository {
+	return &memoryAuditLogRepository{store: newMemoryStore[models.AuditLog]("audit_id")}
+}
+
+func (r *memoryAuditLogRepository) Create(ctx context.Context, entry models.AuditLog) error {
+	r.store.insert(entry.Audit_id, entry)
+	return nil
+}
+
+func (r *memoryAuditLogRepository) List(ctx context.Context, params query.Params) (query.Page[models.AuditLog], error) {
+	return query.Apply(r.store.all(), params)
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"golang-restaurant-management/models"
+	"golang-restaurant-management/query"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type FoodRepository interface {
+	// List returns the page of food items the params select.
+	List(ctx context.Context, params query.Params) (query.Page[models.Food], error)
+	FindByID(ctx context.Context, foodId string) (models.Food, error)
+	Create(ctx context.Context, food models.Food) (InsertResult, error)
+	// Update sets the fields, creating the food item when it does not exist.
+	Update(ctx context.Context, foodId string, fields primitive.D) (UpdateResult, error)
+}
+
+type mongoFoodRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoFoodRepository(db *mongo.Database) FoodRepository {
+	return &mongoFoodRepository{collection: db.Collection(foodCollectionName)}
+}
+
+func (r *mongoFoodRepository) List(ctx context.Context, params query.Params) (query.Page[models.Food], error) {
+	return query.Find[models.Food](ctx, r.collection, bson.M{}, params)
+}
+
+func (r *mongoFoodRepository) FindByID(ctx context.Context, foodId string) (models.Food, error) {
+	var food models.Food
+	err := findOne(ctx, r.collection, bson.M{"food_id": foodId}, &food)
+	return food, err
+}
+
+func (r *mongoFoodRepository) Create(ctx context.Context, food models.Food) (InsertResult, error) {
+	result, err := r.collection.InsertOne(ctx, food)
+	if err != nil {
+		return InsertResult{}, err
+	}
+	return InsertResult{InsertedID: result.InsertedID}, nil
+}
+
+func (r *mongoFoodRepository) Update(ctx context.Context, foodId string, fields primitive.D) (UpdateResult, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"food_id": foodId},
+		bson.D{{Key: "$set", Value: fields}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return UpdateResult{}, err
+	}
+	return newUpdateResult(result), nil
+}
+
+type memoryFoodRepository struct {
+	store *memoryStore[models.Food]
+}
+
+func NewMemoryFoodRepository() FoodRepository {
+	return &memoryFoodRepository{store: newMemoryStore[models.Food]("food_id")}
+}
+
+func (r *memoryFoodRepository) List(ctx context.Context, params query.Params) (query.Page[models.Food], error) {
+	return query.Apply(r.store.all(), params)
+}
+
+func (r *memoryFoodRepository) FindByID(ctx context.Context, foodId string) (models.Food, error) {
+	return r.store.find(foodId)
+}
+
+func (r *memoryFoodRepository) Create(ctx context.Context, food models.Food) (InsertResult, error) {
+	r.store.insert(food.Food_id, food)
+	return InsertResult{InsertedID: food.ID}, nil
+}
+
+func (r *memoryFoodRepository) Update(ctx context.Context, foodId string, fields primitive.D) (UpdateResult, error) {
+	return r.store.update(foodId, fields, true)
+}
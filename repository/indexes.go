@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// loginAttemptTTL is how long a login attempt is kept after its last failure.
+// It has to outlast the longest lockout plus the quiet window after which the
+// counter starts over, both of which are set in helper/loginAttemptHelper.go.
+const loginAttemptTTL = 2 * time.Hour
+
+// indexes lists the indexes the lookups rely on, by collection.
+// Creating an index that already exists with the same options is a no-op, so
+// they are simply created on every start.
+var indexes = map[string][]mongo.IndexModel{
+	// API keys are looked up by the hash of the whole key
+	apiKeyCollectionName: {
+		{Keys: bson.D{{Key: "key_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	// the business metrics join every order to its invoice
+	invoiceCollectionName: {
+		{Keys: bson.D{{Key: "order_id", Value: 1}}},
+	},
+	userCollectionName: {
+		{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	sessionCollectionName: {
+		{Keys: bson.D{{Key: "session_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+	},
+	// every login, PIN and two-factor check looks its counters up by key
+	loginAttemptCollectionName: {
+		{Keys: bson.D{{Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "last_failed_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(loginAttemptTTL.Seconds()))},
+	},
+	// reset and verification links are looked up by the hash of the token,
+	// earlier ones are dropped by user, and expired ones are useless
+	passwordResetCollectionName: {
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	},
+	emailVerificationCollectionName: {
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	},
+	revokedTokenCollectionName: {
+		{Keys: bson.D{{Key: "token_id", Value: 1}}},
+		// a revoked token only matters until it would have expired anyway
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	},
+}
+
+// EnsureIndexes creates the indexes the repositories need.
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	for collection, collectionIndexes := range indexes {
+		if _, err := db.Collection(collection).Indexes().CreateMany(ctx, collectionIndexes); err != nil {
+			return fmt.Errorf("creating indexes on %s: %w", collection, err)
+		}
+	}
+	return nil
+}
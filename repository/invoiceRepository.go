@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"golang-restaurant-management/models"
+	"golang-restaurant-management/query"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type InvoiceRepository interface {
+	// List returns the page of invoices the params select.
+	List(ctx context.Context, params query.Params) (query.Page[models.Invoice], error)
+	FindByID(ctx context.Context, invoiceId string) (models.Invoice, error)
+	Create(ctx context.Context, invoice models.Invoice) (InsertResult, error)
+	// Update sets the fields, creating the invoice when it does not exist.
+	Update(ctx context.Context, invoiceId string, fields primitive.D) (UpdateResult, error)
+}
+
+type mongoInvoiceRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoInvoiceRepository(db *mongo.Database) InvoiceRepository {
+	return &mongoInvoiceRepository{collection: db.Collection(invoiceCollectionName)}
+}
+
+func (r *mongoInvoiceRepository) List(ctx context.Context, params query.Params) (query.Page[models.Invoice], error) {
+	return query.Find[models.Invoice](ctx, r.collection, bson.M{}, params)
+}
+
+func (r *mongoInvoiceRepository) FindByID(ctx context.Context, invoiceId string) (models.Invoice, error) {
+	var invoice models.Invoice
+	err := findOne(ctx, r.collection, bson.M{"invoice_id": invoiceId}, &invoice)
+	return invoice, err
+}
+
+func (r *mongoInvoiceRepository) Create(ctx context.Context, invoice models.Invoice) (InsertResult, error) {
+	result, err := r.collection.InsertOne(ctx, invoice)
+	if err != nil {
+		return InsertResult{}, err
+	}
+	return InsertResult{InsertedID: result.InsertedID}, nil
+}
+
+func (r *mongoInvoiceRepository) Update(ctx context.Context, invoiceId string, fields primitive.D) (UpdateResult, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"invoice_id": invoiceId},
+		bson.D{{Key: "$set", Value: fields}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return UpdateResult{}, err
+	}
+	return newUpdateResult(result), nil
+}
+
+type memoryInvoiceRepository struct {
+	store *memoryStore[models.Invoice]
+}
+
+func NewMemoryInvoiceRepository() InvoiceRepository {
+	return &memoryInvoiceRepository{store: newMemoryStore[models.Invoice]("invoice_id")}
+}
+
+func (r *memoryInvoiceRepository) List(ctx context.Context, params query.Params) (query.Page[models.Invoice], error) {
+	return query.Apply(r.store.all(), params)
+}
+
+func (r *memoryInvoiceRepository) FindByID(ctx context.Context, invoiceId string) (models.Invoice, error) {
+	return r.store.find(invoiceId)
+}
+
+func (r *memoryInvoiceRepository) Create(ctx context.Context, invoice models.Invoice) (InsertResult, error) {
+	r.store.insert(invoice.Invoice_id, invoice)
+	return InsertResult{InsertedID: invoice.ID}, nil
+}
+
+func (r *memoryInvoiceRepository) Update(ctx context.Context, invoiceId string, fields primitive.D) (UpdateResult, error) {
+	return r.store.update(invoiceId, fields, true)
+}
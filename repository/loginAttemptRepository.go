@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"golang-restaurant-management/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LoginAttemptRepository interface {
+	// FindLocked returns the attempts among the keys that are locked at now.
+	FindLocked(ctx context.Context, keys []string, now time.Time) ([]models.LoginAttempt, error)
+	// RecordFailure counts one more failure for the key at now and returns the
+	// updated attempt. A key that has been quiet, and not locked, since
+	// staleBefore starts counting again from zero.
+	RecordFailure(ctx context.Context, key string, now, staleBefore time.Time) (models.LoginAttempt, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Delete(ctx context.Context, keys []string) error
+}
+
+type mongoLoginAttemptRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoLoginAttemptRepository(db *mongo.Database) LoginAttemptRepository {
+	return &mongoLoginAttemptRepository{collection: db.Collection(loginAttemptCollectionName)}
+}
+
+func (r *mongoLoginAttemptRepository) FindLocked(ctx context.Context, keys []string, now time.Time) ([]models.LoginAttempt, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"key":          bson.M{"$in": keys},
+		"locked_until": bson.M{"$gt": now},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var attempts []models.LoginAttempt
+	if err = cursor.All(ctx, &attempts); err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+func (r *mongoLoginAttemptRepository) RecordFailure(ctx context.Context, key string, now, staleBefore time.Time) (models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{
+			"key":            key,
+			"last_failed_at": bson.M{"$lt": staleBefore},
+			"$or": bson.A{
+				bson.M{"locked_until": nil},
+				bson.M{"locked_until": bson.M{"$lt": staleBefore}},
+			},
+		},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "failed_count", Value: 0},
+			{Key: "locked_until", Value: nil},
+		}}},
+	)
+	if err != nil {
+		return attempt, err
+	}
+
+	increment := func() error {
+		return r.collection.FindOneAndUpdate(
+			ctx,
+			bson.M{"key": key},
+			bson.D{
+				{Key: "$inc", Value: bson.D{{Key: "failed_count", Value: 1}}},
+				{Key: "$set", Value: bson.D{{Key: "last_failed_at", Value: now}}},
+			},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(&attempt)
+	}
+
+	// two first failures at the same time can both try to insert; the unique
+	// index on key turns one away, and its retry finds the other's document
+	err = increment()
+	if mongo.IsDuplicateKeyError(err) {
+		err = increment()
+	}
+	return attempt, err
+}
+
+func (r *mongoLoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"key": key},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "locked_until", Value: until}}}},
+	)
+	return err
+}
+
+func (r *mongoLoginAttemptRepository) Delete(ctx context.Context, keys []string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"key": bson.M{"$in": keys}})
+	return err
+}
+
+type memoryLoginAttemptRepository struct {
+	store *memoryStore[models.LoginAttempt]
+}
+
+func NewMemoryLoginAttemptRepository() LoginAttemptRepository {
+	return &memoryLoginAttemptRepository{store: newMemoryStore[models.LoginAttempt]("key")}
+}
+
+func (r *memoryLoginAttemptRepository) FindLocked(ctx context.Context, keys []string, now time.Time) ([]models.LoginAttempt, error) {
+	var attempts []models.LoginAttempt
+	for _, key := range keys {
+		attempt, err := r.store.find(key)
+		if err == ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if attempt.Locked_until != nil && attempt.Locked_until.After(now) {
+			attempts = append(attempts, attempt)
+		}
+	}
+	return attempts, nil
+}
+
+func (r *memoryLoginAttemptRepository) RecordFailure(ctx context.Context, key string, now, staleBefore time.Time) (models.LoginAttempt, error) {
+	return r.store.upsert(key, func(attempt *models.LoginAttempt) {
+		attempt.Key = key
+
+		if attempt.Last_failed_at.Before(staleBefore) && (attempt.Locked_until == nil || attempt.Locked_until.Before(staleBefore)) {
+			attempt.Failed_count = 0
+			attempt.Locked_until = nil
+		}
+
+		attempt.Failed_count++
+		attempt.Last_failed_at = now
+	}), nil
+}
+
+func (r *memoryLoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	_, err := r.store.modify(key, func(attempt *models.LoginAttempt) (bool, error) {
+		attempt.Locked_until = &until
+		return true, nil
+	})
+	return err
+}
+
+func (r *memoryLoginAttemptRepository) Delete(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		r.store.delete(key)
+	}
+	return nil
+}
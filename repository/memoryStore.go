@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryStore is the in-memory counterpart of a collection. Items are kept in
+// insertion order and keyed by their "<entity>_id" field.
+type memoryStore[T any] struct {
+	mu    sync.RWMutex
+	idKey string
+	ids   []string
+	items map[string]T
+}
+
+func newMemoryStore[T any](idKey string) *memoryStore[T] {
+	return &memoryStore[T]{idKey: idKey, items: map[string]T{}}
+}
+
+func (s *memoryStore[T]) all() []T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := make([]T, 0, len(s.ids))
+	for _, id := range s.ids {
+		items = append(items, s.items[id])
+	}
+	return items
+}
+
+func (s *memoryStore[T]) find(id string) (T, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	item, ok := s.items[id]
+	if !ok {
+		return item, ErrNotFound
+	}
+	return item, nil
+}
+
+func (s *memoryStore[T]) insert(id string, item T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.items[id]; !ok {
+		s.ids = append(s.ids, id)
+	}
+	s.items[id] = item
+}
+
+// update works like a $set on the item with the id. With upsert a missing
+// item is created from the id and the fields, as Mongo would.
+func (s *memoryStore[T]) update(id string, fields primitive.D, upsert bool) (UpdateResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[id]
+	if !ok && !upsert {
+		return UpdateResult{}, nil
+	}
+
+	if !ok {
+		fields = append(primitive.D{{Key: s.idKey, Value: id}}, fields...)
+	}
+
+	if err := applyFields(&item, fields); err != nil {
+		return UpdateResult{}, err
+	}
+
+	if !ok {
+		s.ids = append(s.ids, id)
+		s.items[id] = item
+		return UpdateResult{UpsertedCount: 1, UpsertedID: id}, nil
+	}
+
+	s.items[id] = item
+	return UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+}
+
+// modify calls change with the item under the write lock and keeps the
+// result when change reports that it applies. It returns false when there is
+// no such item or change declined.
+func (s *memoryStore[T]) modify(id string, change func(item *T) (bool, error)) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[id]
+	if !ok {
+		return false, nil
+	}
+
+	changed, err := change(&item)
+	if err != nil || !changed {
+		return false, err
+	}
+
+	s.items[id] = item
+	return true, nil
+}
+
+// upsert calls change with the item, or the zero value when there is no item
+// with the id yet, under the write lock and stores and returns the result.
+func (s *memoryStore[T]) upsert(id string, change func(item *T)) T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[id]
+	if !ok {
+		s.ids = append(s.ids, id)
+	}
+
+	change(&item)
+	s.items[id] = item
+	return item
+}
+
+func (s *memoryStore[T]) delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.items[id]; !ok {
+		return
+	}
+
+	delete(s.items, id)
+	for i, existing := range s.ids {
+		if existing == id {
+			s.ids = append(s.ids[:i:i], s.ids[i+1:]...)
+			break
+		}
+	}
+}
+
+// applyFields sets the fields, named as they are stored, on the item by going
+// through its BSON form.
+func applyFields[T any](item *T, fields primitive.D) error {
+	data, err := bson.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	var doc bson.M
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		doc[field.Key] = field.Value
+	}
+
+	if data, err = bson.Marshal(doc); err != nil {
+		return err
+	}
+
+	var updated T
+	if err := bson.Unmarshal(data, &updated); err != nil {
+		return err
+	}
+
+	*item = updated
+	return nil
+}
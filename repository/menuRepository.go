@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"golang-restaurant-management/models"
+	"golang-restaurant-management/query"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MenuRepository interface {
+	// List returns the page of menus the params select.
+	List(ctx context.Context, params query.Params) (query.Page[models.Menu], error)
+	FindByID(ctx context.Context, menuId string) (models.Menu, error)
+	Create(ctx context.Context, menu models.Menu) (InsertResult, error)
+	// Update sets the fields, creating the menu when it does not exist.
+	Update(ctx context.Context, menuId string, fields primitive.D) (UpdateResult, error)
+}
+
+type mongoMenuRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoMenuRepository(db *mongo.Database) MenuRepository {
+	return &mongoMenuRepository{collection: db.Collection(menuCollectionName)}
+}
+
+func (r *mongoMenuRepository) List(ctx context.Context, params query.Params) (query.Page[models.Menu], error) {
+	return query.Find[models.Menu](ctx, r.collection, bson.M{}, params)
+}
+
+func (r *mongoMenuRepository) FindByID(ctx context.Context, menuId string) (models.Menu, error) {
+	var menu models.Menu
+	err := findOne(ctx, r.collection, bson.M{"menu_id": menuId}, &menu)
+	return menu, err
+}
+
+func (r *mongoMenuRepository) Create(ctx context.Context, menu models.Menu) (InsertResult, error) {
+	result, err := r.collection.InsertOne(ctx, menu)
+	if err != nil {
+		return InsertResult{}, err
+	}
+	return InsertResult{InsertedID: result.InsertedID}, nil
+}
+
+func (r *mongoMenuRepository) Update(ctx context.Context, menuId string, fields primitive.D) (UpdateResult, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"menu_id": menuId},
+		bson.D{{Key: "$set", Value: fields}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return UpdateResult{}, err
+	}
+	return newUpdateResult(result), nil
+}
+
+type memoryMenuRepository struct {
+	store *memoryStore[models.Menu]
+}
+
+func NewMemoryMenuRepository() MenuRepository {
+	return &memoryMenuRepository{store: newMemoryStore[models.Menu]("menu_id")}
+}
+
+func (r *memoryMenuRepository) List(ctx context.Context, params query.Params) (query.Page[models.Menu], error) {
+	return query.Apply(r.store.all(), params)
+}
+
+func (r *memoryMenuRepository) FindByID(ctx context.Context, menuId string) (models.Menu, error) {
+	return r.store.find(menuId)
+}
+
+func (r *memoryMenuRepository) Create(ctx context.Context, menu models.Menu) (InsertResult, error) {
+	r.store.insert(menu.Menu_id, menu)
+	return InsertResult{InsertedID: menu.ID}, nil
+}
+
+func (r *memoryMenuRepository) Update(ctx context.Context, menuId string, fields primitive.D) (UpdateResult, error) {
+	return r.store.update(menuId, fields, true)
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"golang-restaurant-management/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// OneTimeTokenRepository stores the tokens of one kind of single-use link,
+// such as password resets or e-mail verifications.
+type OneTimeTokenRepository interface {
+	Create(ctx context.Context, token models.OneTimeToken) error
+	// Consume marks the unused token with this hash as used at now and
+	// returns it. ErrNotFound is returned when the token is unknown, expired
+	// or already used.
+	Consume(ctx context.Context, tokenHash string, now time.Time) (models.OneTimeToken, error)
+	// DeleteUnused deletes the tokens issued to the user that have not been
+	// used yet.
+	DeleteUnused(ctx context.Context, userId string) error
+}
+
+type mongoOneTimeTokenRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoOneTimeTokenRepository(db *mongo.Database, collectionName string) OneTimeTokenRepository {
+	return &mongoOneTimeTokenRepository{collection: db.Collection(collectionName)}
+}
+
+func (r *mongoOneTimeTokenRepository) Create(ctx context.Context, token models.OneTimeToken) error {
+	_, err := r.collection.InsertOne(ctx, token)
+	return err
+}
+
+func (r *mongoOneTimeTokenRepository) Consume(ctx context.Context, tokenHash string, now time.Time) (models.OneTimeToken, error) {
+	var token models.OneTimeToken
+
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{
+			"token_hash": tokenHash,
+			"used_at":    nil,
+			"expires_at": bson.M{"$gt": now},
+		},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "used_at", Value: now}}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&token)
+	if err == mongo.ErrNoDocuments {
+		return token, ErrNotFound
+	}
+	return token, err
+}
+
+func (r *mongoOneTimeTokenRepository) DeleteUnused(ctx context.Context, userId string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userId, "used_at": nil})
+	return err
+}
+
+type memoryOneTimeTokenRepository struct {
+	store *memoryStore[models.OneTimeToken]
+}
+
+// NewMemoryOneTimeTokenRepository keys tokens by their hash, which is unique.
+func NewMemoryOneTimeTokenRepository() OneTimeTokenRepository {
+	return &memoryOneTimeTokenRepository{store: newMemoryStore[models.OneTimeToken]("token_hash")}
+}
+
+func (r *memoryOneTimeTokenRepository) Create(ctx context.Context, token models.OneTimeToken) error {
+	r.store.insert(token.Token_hash, token)
+	return nil
+}
+
+func (r *memoryOneTimeTokenRepository) Consume(ctx context.Context, tokenHash string, now time.Time) (models.OneTimeToken, error) {
+	consumed, err := r.store.modify(tokenHash, func(token *models.OneTimeToken) (bool, error) {
+		if token.Used_at != nil || !token.Expires_at.After(now) {
+			return false, nil
+		}
+		token.Used_at = &now
+		return true, nil
+	})
+	if err != nil {
+		return models.OneTimeToken{}, err
+	}
+	if !consumed {
+		return models.OneTimeToken{}, ErrNotFound
+	}
+	return r.store.find(tokenHash)
+}
+
+func (r *memoryOneTimeTokenRepository) DeleteUnused(ctx context.Context, userId string) error {
+	for _, token := range r.store.all() {
+		if token.User_id == userId && token.Used_at == nil {
+			r.store.delete(token.Token_hash)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"context"
+	"golang-restaurant-management/models"
+	"golang-restaurant-management/query"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OrderItemRepository interface {
+	// List returns the page of order items the params select.
+	List(ctx context.Context, params query.Params) (query.Page[models.OrderItem], error)
+	FindByID(ctx context.Context, orderItemId string) (models.OrderItem, error)
+	// ItemsByOrder returns the items of the order joined with their food and
+	// table, grouped into a single summary with the order total.
+	ItemsByOrder(ctx context.Context, orderId string) ([]primitive.M, error)
+	CreateMany(ctx context.Context, orderItems []models.OrderItem) (InsertManyResult, error)
+	// Update sets the fields, creating the order item when it does not exist.
+	Update(ctx context.Context, orderItemId string, fields primitive.D) (UpdateResult, error)
+}
+
+type mongoOrderItemRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoOrderItemRepository(db *mongo.Database) OrderItemRepository {
+	return &mongoOrderItemRepository{collection: db.Collection(orderItemCollectionName)}
+}
+
+func (r *mongoOrderItemRepository) List(ctx context.Context, params query.Params) (query.Page[models.OrderItem], error) {
+	return query.Find[models.OrderItem](ctx, r.collection, bson.M{}, params)
+}
+
+// the driver stores OrderItem_id as "orderitem_id"
+func (r *mongoOrderItemRepository) FindByID(ctx context.Context, orderItemId string) (models.OrderItem, error) {
+	var orderItem models.OrderItem
+	err := findOne(ctx, r.collection, bson.M{"orderitem_id": orderItemId}, &orderItem)
+	return orderItem, err
+}
+
+func (r *mongoOrderItemRepository) ItemsByOrder(ctx context.Context, orderId string) ([]primitive.M, error) {
+	matchStage := bson.D{{Key: "$match", Value: bson.D{{Key: "order_id", Value: orderId}}}}
+
+	lookupStage := bson.D{
+		{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: foodCollectionName},
+			{Key: "localField", Value: "food_id"},
+			{Key: "foreignField", Value: "food_id"},
+			{Key: "as", Value: "food"},
+		}},
+	}
+	unwindStage := bson.D{
+		{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$food"},
+			{Key: "preserveNullAndEmptyArrays", Value: true},
+		}},
+	}
+
+	lookupOrderStage := bson.D{
+		{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: orderCollectionName},
+			{Key: "localField", Value: "order_id"},
+			{Key: "foreignField", Value: "order_id"},
+			{Key: "as", Value: "order"},
+		}},
+	}
+	unwindOrderStage := bson.D{
+		{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$order"},
+			{Key: "preserveNullAndEmptyArrays", Value: true},
+		}},
+	}
+
+	lookupTableStage := bson.D{
+		{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: tableCollectionName},
+			{Key: "localField", Value: "order.table_id"},
+			{Key: "foreignField", Value: "table_id"},
+			{Key: "as", Value: "table"},
+		}},
+	}
+	unwindTableStage := bson.D{
+		{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$table"},
+			{Key: "preserveNullAndEmptyArrays", Value: true},
+		}},
+	}
+
+	projectStage := bson.D{
+		{
+			Key: "$project", Value: bson.D{
+				{Key: "_id", Value: 0},
+				{Key: "amount", Value: bson.D{{Key: "$multiply", Value: bson.A{"$unit_price", "$quantity"}}}},
+				{Key: "total_count", Value: 1},
+				{Key: "food_name", Value: "$food.name"},
+				{Key: "food_image", Value: "$food.food_image"},
+				{Key: "table_number", Value: "$table.table_number"},
+				{Key: "table_id", Value: "$table.table_id"},
+				{Key: "order_id", Value: "$order.order_id"},
+				{Key: "price", Value: "$food.price"},
+				{Key: "quantity", Value: 1},
+			}}}
+
+	groupStage := bson.D{
+		{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "order_id", Value: "$order_id"},
+				{Key: "table_id", Value: "$table_id"},
+				{Key: "table_number", Value: "$table_number"},
+			}},
+			{Key: "total_amount", Value: bson.D{{Key: "$sum", Value: "$amount"}}},
+			{Key: "total_count", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "order_items", Value: bson.D{{Key: "$push", Value: "$$ROOT"}}},
+		}},
+	}
+
+	projectStage2 := bson.D{
+		{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "total_amount", Value: 1},
+			{Key: "total_count", Value: 1},
+			{Key: "table_number", Value: "$_id.table_number"},
+			{Key: "order_items", Value: 1},
+		}}}
+
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		matchStage,
+		lookupStage,
+		unwindStage,
+		lookupOrderStage,
+		unwindOrderStage,
+		lookupTableStage,
+		unwindTableStage,
+		projectStage,
+		groupStage,
+		projectStage2,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var orderItems []primitive.M
+	if err = cursor.All(ctx, &orderItems); err != nil {
+		return nil, err
+	}
+
+	return orderItems, nil
+}
+
+func (r *mongoOrderItemRepository) CreateMany(ctx context.Context, orderItems []models.OrderItem) (InsertManyResult, error) {
+	documents := make([]interface{}, 0, len(orderItems))
+	for _, orderItem := range orderItems {
+		documents = append(documents, orderItem)
+	}
+
+	result, err := r.collection.InsertMany(ctx, documents)
+	if err != nil {
+		return InsertManyResult{}, err
+	}
+	return InsertManyResult{InsertedIDs: result.InsertedIDs}, nil
+}
+
+func (r *mongoOrderItemRepository) Update(ctx context.Context, orderItemId string, fields primitive.D) (UpdateResult, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"orderitem_id": orderItemId},
+		bson.D{{Key: "$set", Value: fields}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return UpdateResult{}, err
+	}
+	return newUpdateResult(result), nil
+}
+
+type memoryOrderItemRepository struct {
+	store  *memoryStore[models.OrderItem]
+	foods  FoodRepository
+	orders OrderRepository
+	tables TableRepository
+}
+
+// NewMemoryOrderItemRepository needs the food, order and table repositories
+// to join them in ItemsByOrder.
+func NewMemoryOrderItemRepository(foods FoodRepository, orders OrderRepository, tables TableRepository) OrderItemRepository {
+	return &memoryOrderItemRepository{
+		store:  newMemoryStore[models.OrderItem]("orderitem_id"),
+		foods:  foods,
+		orders: orders,
+		tables: tables,
+	}
+}
+
+func (r *memoryOrderItemRepository) List(ctx context.Context, params query.Params) (query.Page[models.OrderItem], error) {
+	return query.Apply(r.store.all(), params)
+}
+
+func (r *memoryOrderItemRepository) FindByID(ctx context.Context, orderItemId string) (models.OrderItem, error) {
+	return r.store.find(orderItemId)
+}
+
+func (r *memoryOrderItemRepository) ItemsByOrder(ctx context.Context, orderId string) ([]primitive.M, error) {
+	var tableNumber interface{}
+
+	order, err := r.orders.FindByID(ctx, orderId)
+	if err != nil && err != ErrNotFound {
+		return nil, err
+	}
+
+	if err == nil && order.Table_id != nil {
+		table, err := r.tables.FindByID(ctx, *order.Table_id)
+		if err != nil && err != ErrNotFound {
+			return nil, err
+		}
+		if err == nil {
+			tableNumber = table.Table_number
+		}
+	}
+
+	var totalAmount float64
+	orderItems := bson.A{}
+
+	for _, orderItem := range r.store.all() {
+		if orderItem.Order_id != orderId {
+			continue
+		}
+
+		item := primitive.M{
+			"order_id":     orderId,
+			"table_number": tableNumber,
+			"quantity":     orderItem.Quantity,
+		}
+
+		if order.Table_id != nil {
+			item["table_id"] = *order.Table_id
+		}
+
+		if orderItem.Unit_price != nil && orderItem.Quantity != nil {
+			amount := *orderItem.Unit_price * float64(*orderItem.Quantity)
+			item["amount"] = amount
+			totalAmount += amount
+		}
+
+		if orderItem.Food_id != nil {
+			food, err := r.foods.FindByID(ctx, *orderItem.Food_id)
+			if err != nil && err != ErrNotFound {
+				return nil, err
+			}
+			if err == nil {
+				item["food_name"] = food.Name
+				item["food_image"] = food.Food_image
+				item["price"] = food.Price
+			}
+		}
+
+		orderItems = append(orderItems, item)
+	}
+
+	if len(orderItems) == 0 {
+		return []primitive.M{}, nil
+	}
+
+	return []primitive.M{{
+		"total_amount": totalAmount,
+		"total_count":  len(orderItems),
+		"table_number": tableNumber,
+		"order_items":  orderItems,
+	}}, nil
+}
+
+func (r *memoryOrderItemRepository) CreateMany(ctx context.Context, orderItems []models.OrderItem) (InsertManyResult, error) {
+	var result InsertManyResult
+
+	for _, orderItem := range orderItems {
+		r.store.insert(orderItem.OrderItem_id, orderItem)
+		result.InsertedIDs = append(result.InsertedIDs, orderItem.ID)
+	}
+
+	return result, nil
+}
+
+func (r *memoryOrderItemRepository) Update(ctx context.Context, orderItemId string, fields primitive.D) (UpdateResult, error) {
+	return r.store.update(orderItemId, fields, true)
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"golang-restaurant-management/models"
+	"golang-restaurant-management/query"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OrderRepository interface {
+	// List returns the page of orders the params select.
+	List(ctx context.Context, params query.Params) (query.Page[models.Order], error)
+	FindByID(ctx context.Context, orderId string) (models.Order, error)
+	Create(ctx context.Context, order models.Order) (InsertResult, error)
+	// Update sets the fields, creating the order when it does not exist.
+	Update(ctx context.Context, orderId string, fields primitive.D) (UpdateResult, error)
+}
+
+type mongoOrderRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoOrderRepository(db *mongo.Database) OrderRepository {
+	return &mongoOrderRepository{collection: db.Collection(orderCollectionName)}
+}
+
+func (r *mongoOrderRepository) List(ctx context.Context, params query.Params) (query.Page[models.Order], error) {
+	return query.Find[models.Order](ctx, r.collection, bson.M{}, params)
+}
+
+func (r *mongoOrderRepository) FindByID(ctx context.Context, orderId string) (models.Order, error) {
+	var order models.Order
+	err := findOne(ctx, r.collection, bson.M{"order_id": orderId}, &order)
+	return order, err
+}
+
+func (r *mongoOrderRepository) Create(ctx context.Context, order models.Order) (InsertResult, error) {
+	result, err := r.collection.InsertOne(ctx, order)
+	if err != nil {
+		return InsertResult{}, err
+	}
+	return InsertResult{InsertedID: result.InsertedID}, nil
+}
+
+func (r *mongoOrderRepository) Update(ctx context.Context, orderId string, fields primitive.D) (UpdateResult, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"order_id": orderId},
+		bson.D{{Key: "$set", Value: fields}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return UpdateResult{}, err
+	}
+	return newUpdateResult(result), nil
+}
+
+type memoryOrderRepository struct {
+	store *memoryStore[models.Order]
+}
+
+func NewMemoryOrderRepository() OrderRepository {
+	return &memoryOrderRepository{store: newMemoryStore[models.Order]("order_id")}
+}
+
+func (r *memoryOrderRepository) List(ctx context.Context, params query.Params) (query.Page[models.Order], error) {
+	return query.Apply(r.store.all(), params)
+}
+
+func (r *memoryOrderRepository) FindByID(ctx context.Context, orderId string) (models.Order, error) {
+	return r.store.find(orderId)
+}
+
+func (r *memoryOrderRepository) Create(ctx context.Context, order models.Order) (InsertResult, error) {
+	r.store.insert(order.Order_id, order)
+	return InsertResult{InsertedID: order.ID}, nil
+}
+
+func (r *memoryOrderRepository) Update(ctx context.Context, orderId string, fields primitive.D) (UpdateResult, error) {
+	return r.store.update(orderId, fields, true)
+}
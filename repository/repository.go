@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	foodCollectionName      = "food"
+	menuCollectionName      = "menu"
+	tableCollectionName     = "tables"
+	orderCollectionName     = "order"
+	orderItemCollectionName = "order_items"
+	invoiceCollectionName   = "invoice"
+	userCollectionName      = "user"
+
+	sessionCollectionName           = "sessions"
+	revokedTokenCollectionName      = "revoked_tokens"
+	apiKeyCollectionName            = "api_keys"
+	terminalCollectionName          = "terminals"
+	auditLogCollectionName          = "audit_logs"
+	loginAttemptCollectionName      = "login_attempts"
+	passwordResetCollectionName     = "password_resets"
+	emailVerificationCollectionName = "email_verifications"
+	securityEventCollectionName     = "security_events"
+)
+
+// ErrNotFound is returned by the Find methods when there is no such document.
+var ErrNotFound = errors.New("document not found")
+
+type InsertResult struct {
+	InsertedID interface{}
+}
+
+type InsertManyResult struct {
+	InsertedIDs []interface{}
+}
+
+type UpdateResult struct {
+	MatchedCount  int64
+	ModifiedCount int64
+	UpsertedCount int64
+	UpsertedID    interface{}
+}
+
+// Repositories groups the repository of every model so they can be built
+// once and handed to the controllers and middlewares.
+type Repositories struct {
+	Foods      FoodRepository
+	Menus      MenuRepository
+	Tables     TableRepository
+	Orders     OrderRepository
+	OrderItems OrderItemRepository
+	Invoices   InvoiceRepository
+	Users      UserRepository
+	Stats      StatsRepository
+
+	Sessions           SessionRepository
+	RevokedTokens      RevokedTokenRepository
+	ApiKeys            ApiKeyRepository
+	Terminals          TerminalRepository
+	AuditLogs          AuditLogRepository
+	LoginAttempts      LoginAttemptRepository
+	PasswordResets     OneTimeTokenRepository
+	EmailVerifications OneTimeTokenRepository
+	SecurityEvents     SecurityEventRepository
+}
+
+func NewMongoRepositories(db *mongo.Database) *Repositories {
+	return &Repositories{
+		Foods:      NewMongoFoodRepository(db),
+		Menus:      NewMongoMenuRepository(db),
+		Tables:     NewMongoTableRepository(db),
+		Orders:     NewMongoOrderRepository(db),
+		OrderItems: NewMongoOrderItemRepository(db),
+		Invoices:   NewMongoInvoiceRepository(db),
+		Users:      NewMongoUserRepository(db),
+		Stats:      NewMongoStatsRepository(db),
+
+		Sessions:           NewMongoSessionRepository(db),
+		RevokedTokens:      NewMongoRevokedTokenRepository(db),
+		ApiKeys:            NewMongoApiKeyRepository(db),
+		Terminals:          NewMongoTerminalRepository(db),
+		AuditLogs:          NewMongoAuditLogRepository(db),
+		LoginAttempts:      NewMongoLoginAttemptRepository(db),
+		PasswordResets:     NewMongoOneTimeTokenRepository(db, passwordResetCollectionName),
+		EmailVerifications: NewMongoOneTimeTokenRepository(db, emailVerificationCollectionName),
+		SecurityEvents:     NewMongoSecurityEventRepository(db),
+	}
+}
+
+// NewMemoryRepositories keeps everything in process memory, for tests and
+// for running the API without a database.
+func NewMemoryRepositories() *Repositories {
+	foods := NewMemoryFoodRepository()
+	orders := NewMemoryOrderRepository()
+	tables := NewMemoryTableRepository()
+	invoices := NewMemoryInvoiceRepository()
+
+	return &Repositories{
+		Foods:      foods,
+		Menus:      NewMemoryMenuRepository(),
+		Tables:     tables,
+		Orders:     orders,
+		OrderItems: NewMemoryOrderItemRepository(foods, orders, tables),
+		Invoices:   invoices,
+		Users:      NewMemoryUserRepository(),
+		Stats:      NewMemoryStatsRepository(orders, invoices),
+
+		Sessions:           NewMemorySessionRepository(),
+		RevokedTokens:      NewMemoryRevokedTokenRepository(),
+		ApiKeys:            NewMemoryApiKeyRepository(),
+		Terminals:          NewMemoryTerminalRepository(),
+		AuditLogs:          NewMemoryAuditLogRepository(),
+		LoginAttempts:      NewMemoryLoginAttemptRepository(),
+		PasswordResets:     NewMemoryOneTimeTokenRepository(),
+		EmailVerifications: NewMemoryOneTimeTokenRepository(),
+		SecurityEvents:     NewMemorySecurityEventRepository(),
+	}
+}
+
+func findOne(ctx context.Context, collection *mongo.Collection, filter interface{}, out interface{}) error {
+	err := collection.FindOne(ctx, filter).Decode(out)
+	if err == mongo.ErrNoDocuments {
+		return ErrNotFound
+	}
+	return err
+}
+
+func newUpdateResult(result *mongo.UpdateResult) UpdateResult {
+	return UpdateResult{
+		MatchedCount:  result.MatchedCount,
+		ModifiedCount: result.ModifiedCount,
+		UpsertedCount: result.UpsertedCount,
+		UpsertedID:    result.UpsertedID,
+	}
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"golang-restaurant-management/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type RevokedTokenRepository interface {
+	Create(ctx context.Context, revoked models.RevokedToken) error
+	// Exists reports whether the token with this JWT ID has been revoked.
+	Exists(ctx context.Context, tokenId string) (bool, error)
+}
+
+type mongoRevokedTokenRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoRevokedTokenRepository(db *mongo.Database) RevokedTokenRepository {
+	return &mongoRevokedTokenRepository{collection: db.Collection(revokedTokenCollectionName)}
+}
+
+func (r *mongoRevokedTokenRepository) Create(ctx context.Context, revoked models.RevokedToken) error {
+	_, err := r.collection.InsertOne(ctx, revoked)
+	return err
+}
+
+func (r *mongoRevokedTokenRepository) Exists(ctx context.Context, tokenId string) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"token_id": tokenId})
+	return count > 0, err
+}
+
+type memoryRevokedTokenRepository struct {
+	store *memoryStore[models.RevokedToken]
+}
+
+func NewMemoryRevokedTokenRepository() RevokedTokenRepository {
+	return &memoryRevokedTokenRepository{store: newMemoryStore[models.RevokedToken]("token_id")}
+}
+
+func (r *memoryRevokedTokenRepository) Create(ctx context.Context, revoked models.RevokedToken) error {
+	r.store.insert(revoked.Token_id, revoked)
+	return nil
+}
+
+func (r *memoryRevokedTokenRepository) Exists(ctx context.Context, tokenId string) (bool, error) {
+	_, err := r.store.find(tokenId)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"golang-restaurant-management/models"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type SecurityEventRepository interface {
+	Create(ctx context.Context, event models.SecurityEvent) error
+}
+
+type mongoSecurityEventRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoSecurityEventRepository(db *mongo.Database) SecurityEventRepository {
+	return &mongoSecurityEventRepository{collection: db.Collection(securityEventCollectionName)}
+}
+
+func (r *mongoSecurityEventRepository) Create(ctx context.Context, event models.SecurityEvent) error {
+	_, err := r.collection.InsertOne(ctx, event)
+	return err
+}
+
+type memorySecurityEventRepository struct {
+	store *memoryStore[models.SecurityEvent]
+}
+
+func NewMemorySecurityEventRepository() SecurityEventRepository {
+	return &memorySecurityEventRepository{store: newMemoryStore[models.SecurityEvent]("event_id")}
+}
+
+func (r *memorySecurityEventRepository) Create(ctx context.Context, event models.SecurityEvent) error {
+	r.store.insert(event.Event_id, event)
+	return nil
+}
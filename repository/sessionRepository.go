@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"golang-restaurant-management/models"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session models.Session) error
+	FindByID(ctx context.Context, sessionId string) (models.Session, error)
+	// ListActive returns the user's sessions that have not been revoked, most
+	// recently used first.
+	ListActive(ctx context.Context, userId string) ([]models.Session, error)
+	// Rotate replaces the refresh token hash of an active session while
+	// presentedHash is still its current one. It reports false when the token
+	// has already been rotated by another request.
+	Rotate(ctx context.Context, sessionId, presentedHash, refreshTokenHash, ipAddress string, at time.Time) (bool, error)
+	// Revoke ends one active session of the user. It reports false when no
+	// such session exists.
+	Revoke(ctx context.Context, sessionId, userId string, at time.Time) (bool, error)
+	RevokeAll(ctx context.Context, userId string, at time.Time) error
+	// RevokeOthers ends every active session of the user except keepSessionId.
+	RevokeOthers(ctx context.Context, userId, keepSessionId string, at time.Time) error
+}
+
+type mongoSessionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoSessionRepository(db *mongo.Database) SessionRepository {
+	return &mongoSessionRepository{collection: db.Collection(sessionCollectionName)}
+}
+
+func (r *mongoSessionRepository) Create(ctx context.Context, session models.Session) error {
+	_, err := r.collection.InsertOne(ctx, session)
+	return err
+}
+
+func (r *mongoSessionRepository) FindByID(ctx context.Context, sessionId string) (models.Session, error) {
+	var session models.Session
+	err := findOne(ctx, r.collection, bson.M{"session_id": sessionId}, &session)
+	return session, err
+}
+
+func (r *mongoSessionRepository) ListActive(ctx context.Context, userId string) ([]models.Session, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "last_used_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userId, "revoked_at": nil}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []models.Session{}
+	if err = cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *mongoSessionRepository) Rotate(ctx context.Context, sessionId, presentedHash, refreshTokenHash, ipAddress string, at time.Time) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"session_id": sessionId, "refresh_token_hash": presentedHash, "revoked_at": nil},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "refresh_token_hash", Value: refreshTokenHash},
+			{Key: "ip_address", Value: ipAddress},
+			{Key: "last_used_at", Value: at},
+		}}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (r *mongoSessionRepository) Revoke(ctx context.Context, sessionId, userId string, at time.Time) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"session_id": sessionId, "user_id": userId, "revoked_at": nil},
+		revokeSessionUpdate(at),
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (r *mongoSessionRepository) RevokeAll(ctx context.Context, userId string, at time.Time) error {
+	_, err := r.collection.UpdateMany(ctx, bson.M{"user_id": userId, "revoked_at": nil}, revokeSessionUpdate(at))
+	return err
+}
+
+func (r *mongoSessionRepository) RevokeOthers(ctx context.Context, userId, keepSessionId string, at time.Time) error {
+	_, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"user_id": userId, "session_id": bson.M{"$ne": keepSessionId}, "revoked_at": nil},
+		revokeSessionUpdate(at),
+	)
+	return err
+}
+
+func revokeSessionUpdate(at time.Time) bson.D {
+	return bson.D{{Key: "$set", Value: bson.D{
+		{Key: "revoked_at", Value: at},
+		{Key: "refresh_token_hash", Value: ""},
+	}}}
+}
+
+type memorySessionRepository struct {
+	store *memoryStore[models.Session]
+}
+
+func NewMemorySessionRepository() SessionRepository {
+	return &memorySessionRepository{store: newMemoryStore[models.Session]("session_id")}
+}
+
+func (r *memorySessionRepository) Create(ctx context.Context, session models.Session) error {
+	r.store.insert(session.Session_id, session)
+	return nil
+}
+
+func (r *memorySessionRepository) FindByID(ctx context.Context, sessionId string) (models.Session, error) {
+	return r.store.find(sessionId)
+}
+
+func (r *memorySessionRepository) ListActive(ctx context.Context, userId string) ([]models.Session, error) {
+	sessions := []models.Session{}
+	for _, session := range r.store.all() {
+		if session.User_id == userId && session.Revoked_at == nil {
+			sessions = append(sessions, session)
+		}
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].Last_used_at.After(sessions[j].Last_used_at)
+	})
+	return sessions, nil
+}
+
+func (r *memorySessionRepository) Rotate(ctx context.Context, sessionId, presentedHash, refreshTokenHash, ipAddress string, at time.Time) (bool, error) {
+	return r.store.modify(sessionId, func(session *models.Session) (bool, error) {
+		if session.Revoked_at != nil || session.Refresh_token_hash != presentedHash {
+			return false, nil
+		}
+		session.Refresh_token_hash = refreshTokenHash
+		session.Ip_address = ipAddress
+		session.Last_used_at = at
+		return true, nil
+	})
+}
+
+func (r *memorySessionRepository) Revoke(ctx context.Context, sessionId, userId string, at time.Time) (bool, error) {
+	return r.store.modify(sessionId, func(session *models.Session) (bool, error) {
+		if session.User_id != userId {
+			return false, nil
+		}
+		return revokeSession(session, at), nil
+	})
+}
+
+func (r *memorySessionRepository) RevokeAll(ctx context.Context, userId string, at time.Time) error {
+	return r.RevokeOthers(ctx, userId, "", at)
+}
+
+func (r *memorySessionRepository) RevokeOthers(ctx context.Context, userId, keepSessionId string, at time.Time) error {
+	for _, session := range r.store.all() {
+		if session.User_id != userId || session.Session_id == keepSessionId {
+			continue
+		}
+
+		if _, err := r.store.modify(session.Session_id, func(session *models.Session) (bool, error) {
+			return revokeSession(session, at), nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revokeSession reports false when the session already was revoked.
+func revokeSession(session *models.Session, at time.Time) bool {
+	if session.Revoked_at != nil {
+		return false
+	}
+	session.Revoked_at = &at
+	session.Refresh_token_hash = ""
+	return true
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"golang-restaurant-management/query"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Stats is a snapshot of the restaurant floor. An order is open until it has a
+// PAID invoice, and a table is occupied while it has an open order.
+type Stats struct {
+	OpenOrders     int64
+	UnpaidInvoices int64
+	OccupiedTables int64
+}
+
+type StatsRepository interface {
+	Current(ctx context.Context) (Stats, error)
+}
+
+type mongoStatsRepository struct {
+	orders   *mongo.Collection
+	invoices *mongo.Collection
+}
+
+func NewMongoStatsRepository(db *mongo.Database) StatsRepository {
+	return &mongoStatsRepository{
+		orders:   db.Collection(orderCollectionName),
+		invoices: db.Collection(invoiceCollectionName),
+	}
+}
+
+func (r *mongoStatsRepository) Current(ctx context.Context) (Stats, error) {
+	var stats Stats
+
+	unpaid, err := r.invoices.CountDocuments(ctx, bson.M{"payment_status": bson.M{"$ne": "PAID"}})
+	if err != nil {
+		return stats, err
+	}
+	stats.UnpaidInvoices = unpaid
+
+	lookupInvoiceStage := bson.D{{Key: "$lookup", Value: bson.D{{Key: "from", Value: invoiceCollectionName}, {Key: "localField", Value: "order_id"}, {Key: "foreignField", Value: "order_id"}, {Key: "as", Value: "invoice"}}}}
+	matchOpenStage := bson.D{{Key: "$match", Value: bson.D{{Key: "invoice.payment_status", Value: bson.D{{Key: "$ne", Value: "PAID"}}}}}}
+	groupByTableStage := bson.D{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$table_id"}, {Key: "orders", Value: bson.D{{Key: "$sum", Value: 1}}}}}}
+
+	cursor, err := r.orders.Aggregate(ctx, mongo.Pipeline{lookupInvoiceStage, matchOpenStage, groupByTableStage})
+	if err != nil {
+		return stats, err
+	}
+
+	var tables []struct {
+		TableId *string `bson:"_id"`
+		Orders  int64   `bson:"orders"`
+	}
+	if err := cursor.All(ctx, &tables); err != nil {
+		return stats, err
+	}
+
+	for _, table := range tables {
+		stats.OpenOrders += table.Orders
+		if table.TableId != nil {
+			stats.OccupiedTables++
+		}
+	}
+
+	return stats, nil
+}
+
+type memoryStatsRepository struct {
+	orders   OrderRepository
+	invoices InvoiceRepository
+}
+
+func NewMemoryStatsRepository(orders OrderRepository, invoices InvoiceRepository) StatsRepository {
+	return &memoryStatsRepository{orders: orders, invoices: invoices}
+}
+
+func (r *memoryStatsRepository) Current(ctx context.Context) (Stats, error) {
+	var stats Stats
+
+	invoices, err := r.invoices.List(ctx, query.Params{})
+	if err != nil {
+		return stats, err
+	}
+
+	paidOrders := map[string]bool{}
+	for _, invoice := range invoices.Items {
+		if invoice.Payment_status != nil && *invoice.Payment_status == "PAID" {
+			paidOrders[invoice.Order_id] = true
+		} else {
+			stats.UnpaidInvoices++
+		}
+	}
+
+	orders, err := r.orders.List(ctx, query.Params{})
+	if err != nil {
+		return stats, err
+	}
+
+	occupiedTables := map[string]bool{}
+	for _, order := range orders.Items {
+		if paidOrders[order.Order_id] {
+			continue
+		}
+		stats.OpenOrders++
+		if order.Table_id != nil {
+			occupiedTables[*order.Table_id] = true
+		}
+	}
+	stats.OccupiedTables = int64(len(occupiedTables))
+
+	return stats, nil
+}
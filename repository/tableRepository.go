@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"golang-restaurant-management/models"
+	"golang-restaurant-management/query"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TableRepository interface {
+	// List returns the page of tables the params select.
+	List(ctx context.Context, params query.Params) (query.Page[models.Table], error)
+	FindByID(ctx context.Context, tableId string) (models.Table, error)
+	Create(ctx context.Context, table models.Table) (InsertResult, error)
+	// Update sets the fields, creating the table when it does not exist.
+	Update(ctx context.Context, tableId string, fields primitive.D) (UpdateResult, error)
+}
+
+type mongoTableRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoTableRepository(db *mongo.Database) TableRepository {
+	return &mongoTableRepository{collection: db.Collection(tableCollectionName)}
+}
+
+func (r *mongoTableRepository) List(ctx context.Context, params query.Params) (query.Page[models.Table], error) {
+	return query.Find[models.Table](ctx, r.collection, bson.M{}, params)
+}
+
+func (r *mongoTableRepository) FindByID(ctx context.Context, tableId string) (models.Table, error) {
+	var table models.Table
+	err := findOne(ctx, r.collection, bson.M{"table_id": tableId}, &table)
+	return table, err
+}
+
+func (r *mongoTableRepository) Create(ctx context.Context, table models.Table) (InsertResult, error) {
+	result, err := r.collection.InsertOne(ctx, table)
+	if err != nil {
+		return InsertResult{}, err
+	}
+	return InsertResult{InsertedID: result.InsertedID}, nil
+}
+
+func (r *mongoTableRepository) Update(ctx context.Context, tableId string, fields primitive.D) (UpdateResult, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"table_id": tableId},
+		bson.D{{Key: "$set", Value: fields}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return UpdateResult{}, err
+	}
+	return newUpdateResult(result), nil
+}
+
+type memoryTableRepository struct {
+	store *memoryStore[models.Table]
+}
+
+func NewMemoryTableRepository() TableRepository {
+	return &memoryTableRepository{store: newMemoryStore[models.Table]("table_id")}
+}
+
+func (r *memoryTableRepository) List(ctx context.Context, params query.Params) (query.Page[models.Table], error) {
+	return query.Apply(r.store.all(), params)
+}
+
+func (r *memoryTableRepository) FindByID(ctx context.Context, tableId string) (models.Table, error) {
+	return r.store.find(tableId)
+}
+
+func (r *memoryTableRepository) Create(ctx context.Context, table models.Table) (InsertResult, error) {
+	r.store.insert(table.Table_id, table)
+	return InsertResult{InsertedID: table.ID}, nil
+}
+
+func (r *memoryTableRepository) Update(ctx context.Context, tableId string, fields primitive.D) (UpdateResult, error) {
+	return r.store.update(tableId, fields, true)
+}
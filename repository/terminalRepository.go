@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"golang-restaurant-management/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type TerminalRepository interface {
+	Create(ctx context.Context, terminal models.Terminal) error
+	// ListActive returns the terminals that have not been revoked.
+	ListActive(ctx context.Context) ([]models.Terminal, error)
+	FindByID(ctx context.Context, terminalId string) (models.Terminal, error)
+	// FindActiveByTokenHash returns the unrevoked terminal the token was
+	// issued to.
+	FindActiveByTokenHash(ctx context.Context, tokenHash string) (models.Terminal, error)
+	// Revoke reports false when there is no active terminal with this id.
+	Revoke(ctx context.Context, terminalId string, at time.Time) (bool, error)
+	// Touch records that the terminal was used at.
+	Touch(ctx context.Context, terminalId string, at time.Time) error
+}
+
+type mongoTerminalRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoTerminalRepository(db *mongo.Database) TerminalRepository {
+	return &mongoTerminalRepository{collection: db.Collection(terminalCollectionName)}
+}
+
+func (r *mongoTerminalRepository) Create(ctx context.Context, terminal models.Terminal) error {
+	_, err := r.collection.InsertOne(ctx, terminal)
+	return err
+}
+
+func (r *mongoTerminalRepository) ListActive(ctx context.Context) ([]models.Terminal, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"revoked_at": nil})
+	if err != nil {
+		return nil, err
+	}
+
+	terminals := []models.Terminal{}
+	if err = cursor.All(ctx, &terminals); err != nil {
+		return nil, err
+	}
+	return terminals, nil
+}
+
+func (r *mongoTerminalRepository) FindByID(ctx context.Context, terminalId string) (models.Terminal, error) {
+	var terminal models.Terminal
+	err := findOne(ctx, r.collection, bson.M{"terminal_id": terminalId}, &terminal)
+	return terminal, err
+}
+
+func (r *mongoTerminalRepository) FindActiveByTokenHash(ctx context.Context, tokenHash string) (models.Terminal, error) {
+	var terminal models.Terminal
+	err := findOne(ctx, r.collection, bson.M{"token_hash": tokenHash, "revoked_at": nil}, &terminal)
+	return terminal, err
+}
+
+func (r *mongoTerminalRepository) Revoke(ctx context.Context, terminalId string, at time.Time) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"terminal_id": terminalId, "revoked_at": nil},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "revoked_at", Value: at}}}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (r *mongoTerminalRepository) Touch(ctx context.Context, terminalId string, at time.Time) error {
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"terminal_id": terminalId},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "last_used_at", Value: at}}}},
+	)
+	return err
+}
+
+type memoryTerminalRepository struct {
+	store *memoryStore[models.Terminal]
+}
+
+func NewMemoryTerminalRepository() TerminalRepository {
+	return &memoryTerminalRepository{store: newMemoryStore[models.Terminal]("terminal_id")}
+}
+
+func (r *memoryTerminalRepository) Create(ctx context.Context, terminal models.Terminal) error {
+	r.store.insert(terminal.Terminal_id, terminal)
+	return nil
+}
+
+func (r *memoryTerminalRepository) ListActive(ctx context.Context) ([]models.Terminal, error) {
+	terminals := []models.Terminal{}
+	for _, terminal := range r.store.all() {
+		if terminal.Revoked_at == nil {
+			terminals = append(terminals, terminal)
+		}
+	}
+	return terminals, nil
+}
+
+func (r *memoryTerminalRepository) FindByID(ctx context.Context, terminalId string) (models.Terminal, error) {
+	return r.store.find(terminalId)
+}
+
+func (r *memoryTerminalRepository) FindActiveByTokenHash(ctx context.Context, tokenHash string) (models.Terminal, error) {
+	for _, terminal := range r.store.all() {
+		if terminal.Token_hash == tokenHash && terminal.Revoked_at == nil {
+			return terminal, nil
+		}
+	}
+	return models.Terminal{}, ErrNotFound
+}
+
+func (r *memoryTerminalRepository) Revoke(ctx context.Context, terminalId string, at time.Time) (bool, error) {
+	return r.store.modify(terminalId, func(terminal *models.Terminal) (bool, error) {
+		if terminal.Revoked_at != nil {
+			return false, nil
+		}
+		terminal.Revoked_at = &at
+		return true, nil
+	})
+}
+
+func (r *memoryTerminalRepository) Touch(ctx context.Context, terminalId string, at time.Time) error {
+	_, err := r.store.modify(terminalId, func(terminal *models.Terminal) (bool, error) {
+		terminal.Last_used_at = &at
+		return true, nil
+	})
+	return err
+}
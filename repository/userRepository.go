@@ -0,0 +1,296 @@
+package repository
+
+import (
+	"context"
+	"golang-restaurant-management/models"
+	"golang-restaurant-management/query"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// UserFilter narrows down List. Search matches the start of the names, e-mail
+// and phone case-insensitively; a nil Active matches every account.
+type UserFilter struct {
+	Search string
+	Role   string
+	Active *bool
+}
+
+type UserRepository interface {
+	// List returns the page of users matching the filter that the params
+	// select.
+	List(ctx context.Context, filter UserFilter, params query.Params) (query.Page[models.User], error)
+	FindByID(ctx context.Context, userId string) (models.User, error)
+	FindByEmail(ctx context.Context, email string) (models.User, error)
+	Count(ctx context.Context) (int64, error)
+	// EmailExists and PhoneExists ignore the user with exceptUserId, so an
+	// account keeping its own address is not a conflict.
+	EmailExists(ctx context.Context, email, exceptUserId string) (bool, error)
+	PhoneExists(ctx context.Context, phone, exceptUserId string) (bool, error)
+	Create(ctx context.Context, user models.User) error
+	Update(ctx context.Context, userId string, fields primitive.D) (UpdateResult, error)
+	// Deactivate and Reactivate report false when the user does not exist or
+	// already is in that state.
+	Deactivate(ctx context.Context, userId string, at time.Time) (bool, error)
+	Reactivate(ctx context.Context, userId string, at time.Time) (bool, error)
+	// UseTOTPStep records the time step of an accepted code. It reports false
+	// when the step is not newer than the last one, so a code works only once.
+	UseTOTPStep(ctx context.Context, userId string, step int64) (bool, error)
+	// UseRecoveryCode removes the hashed recovery code and reports whether the
+	// user still had it.
+	UseRecoveryCode(ctx context.Context, userId, hashedCode string) (bool, error)
+}
+
+type mongoUserRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoUserRepository(db *mongo.Database) UserRepository {
+	return &mongoUserRepository{collection: db.Collection(userCollectionName)}
+}
+
+func (r *mongoUserRepository) List(ctx context.Context, filter UserFilter, params query.Params) (query.Page[models.User], error) {
+	conditions := bson.M{}
+
+	if filter.Search != "" {
+		pattern := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(filter.Search), Options: "i"}
+		conditions["$or"] = bson.A{
+			bson.M{"first_name": pattern},
+			bson.M{"last_name": pattern},
+			bson.M{"email": pattern},
+			bson.M{"phone": pattern},
+		}
+	}
+
+	if filter.Role != "" {
+		conditions["role"] = filter.Role
+	}
+
+	if filter.Active != nil {
+		if *filter.Active {
+			conditions["deactivated_at"] = nil
+		} else {
+			conditions["deactivated_at"] = bson.M{"$ne": nil}
+		}
+	}
+
+	return query.Find[models.User](ctx, r.collection, conditions, params)
+}
+
+func (r *mongoUserRepository) FindByID(ctx context.Context, userId string) (models.User, error) {
+	var user models.User
+	err := findOne(ctx, r.collection, bson.M{"user_id": userId}, &user)
+	return user, err
+}
+
+func (r *mongoUserRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	var user models.User
+	err := findOne(ctx, r.collection, bson.M{"email": email}, &user)
+	return user, err
+}
+
+func (r *mongoUserRepository) Count(ctx context.Context) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{})
+}
+
+func (r *mongoUserRepository) EmailExists(ctx context.Context, email, exceptUserId string) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"email": email, "user_id": bson.M{"$ne": exceptUserId}})
+	return count > 0, err
+}
+
+func (r *mongoUserRepository) PhoneExists(ctx context.Context, phone, exceptUserId string) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"phone": phone, "user_id": bson.M{"$ne": exceptUserId}})
+	return count > 0, err
+}
+
+func (r *mongoUserRepository) Create(ctx context.Context, user models.User) error {
+	_, err := r.collection.InsertOne(ctx, user)
+	return err
+}
+
+func (r *mongoUserRepository) Update(ctx context.Context, userId string, fields primitive.D) (UpdateResult, error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"user_id": userId}, bson.D{{Key: "$set", Value: fields}})
+	if err != nil {
+		return UpdateResult{}, err
+	}
+	return newUpdateResult(result), nil
+}
+
+func (r *mongoUserRepository) Deactivate(ctx context.Context, userId string, at time.Time) (bool, error) {
+	return r.updateWhere(
+		ctx,
+		bson.M{"user_id": userId, "deactivated_at": nil},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "deactivated_at", Value: at},
+			{Key: "updated_at", Value: at},
+		}}},
+	)
+}
+
+func (r *mongoUserRepository) Reactivate(ctx context.Context, userId string, at time.Time) (bool, error) {
+	return r.updateWhere(
+		ctx,
+		bson.M{"user_id": userId, "deactivated_at": bson.M{"$ne": nil}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "deactivated_at", Value: nil},
+			{Key: "updated_at", Value: at},
+		}}},
+	)
+}
+
+func (r *mongoUserRepository) UseTOTPStep(ctx context.Context, userId string, step int64) (bool, error) {
+	return r.updateWhere(
+		ctx,
+		bson.M{"user_id": userId, "mfa_last_step": bson.M{"$lt": step}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "mfa_last_step", Value: step}}}},
+	)
+}
+
+func (r *mongoUserRepository) UseRecoveryCode(ctx context.Context, userId, hashedCode string) (bool, error) {
+	return r.updateWhere(
+		ctx,
+		bson.M{"user_id": userId, "mfa_recovery_codes": hashedCode},
+		bson.D{{Key: "$pull", Value: bson.D{{Key: "mfa_recovery_codes", Value: hashedCode}}}},
+	)
+}
+
+func (r *mongoUserRepository) updateWhere(ctx context.Context, filter bson.M, update bson.D) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+type memoryUserRepository struct {
+	store *memoryStore[models.User]
+}
+
+func NewMemoryUserRepository() UserRepository {
+	return &memoryUserRepository{store: newMemoryStore[models.User]("user_id")}
+}
+
+func (r *memoryUserRepository) List(ctx context.Context, filter UserFilter, params query.Params) (query.Page[models.User], error) {
+	search := strings.ToLower(filter.Search)
+
+	users := []models.User{}
+	for _, user := range r.store.all() {
+		if search != "" && !hasPrefixFold(search, user.First_name, user.Last_name, user.Email, user.Phone) {
+			continue
+		}
+
+		if filter.Role != "" && (user.Role == nil || *user.Role != filter.Role) {
+			continue
+		}
+
+		if filter.Active != nil && *filter.Active != (user.Deactivated_at == nil) {
+			continue
+		}
+
+		users = append(users, user)
+	}
+
+	return query.Apply(users, params)
+}
+
+func (r *memoryUserRepository) FindByID(ctx context.Context, userId string) (models.User, error) {
+	return r.store.find(userId)
+}
+
+func (r *memoryUserRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	for _, user := range r.store.all() {
+		if user.Email != nil && *user.Email == email {
+			return user, nil
+		}
+	}
+	return models.User{}, ErrNotFound
+}
+
+func (r *memoryUserRepository) Count(ctx context.Context) (int64, error) {
+	return int64(len(r.store.all())), nil
+}
+
+func (r *memoryUserRepository) EmailExists(ctx context.Context, email, exceptUserId string) (bool, error) {
+	for _, user := range r.store.all() {
+		if user.User_id != exceptUserId && user.Email != nil && *user.Email == email {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryUserRepository) PhoneExists(ctx context.Context, phone, exceptUserId string) (bool, error) {
+	for _, user := range r.store.all() {
+		if user.User_id != exceptUserId && user.Phone != nil && *user.Phone == phone {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user models.User) error {
+	r.store.insert(user.User_id, user)
+	return nil
+}
+
+func (r *memoryUserRepository) Update(ctx context.Context, userId string, fields primitive.D) (UpdateResult, error) {
+	return r.store.update(userId, fields, false)
+}
+
+func (r *memoryUserRepository) Deactivate(ctx context.Context, userId string, at time.Time) (bool, error) {
+	return r.store.modify(userId, func(user *models.User) (bool, error) {
+		if user.Deactivated_at != nil {
+			return false, nil
+		}
+		user.Deactivated_at = &at
+		user.Updated_at = at
+		return true, nil
+	})
+}
+
+func (r *memoryUserRepository) Reactivate(ctx context.Context, userId string, at time.Time) (bool, error) {
+	return r.store.modify(userId, func(user *models.User) (bool, error) {
+		if user.Deactivated_at == nil {
+			return false, nil
+		}
+		user.Deactivated_at = nil
+		user.Updated_at = at
+		return true, nil
+	})
+}
+
+func (r *memoryUserRepository) UseTOTPStep(ctx context.Context, userId string, step int64) (bool, error) {
+	return r.store.modify(userId, func(user *models.User) (bool, error) {
+		if user.Mfa_last_step >= step {
+			return false, nil
+		}
+		user.Mfa_last_step = step
+		return true, nil
+	})
+}
+
+func (r *memoryUserRepository) UseRecoveryCode(ctx context.Context, userId, hashedCode string) (bool, error) {
+	return r.store.modify(userId, func(user *models.User) (bool, error) {
+		for i, code := range user.Mfa_recovery_codes {
+			if code == hashedCode {
+				user.Mfa_recovery_codes = append(user.Mfa_recovery_codes[:i:i], user.Mfa_recovery_codes[i+1:]...)
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+func hasPrefixFold(prefix string, values ...*string) bool {
+	for _, value := range values {
+		if value != nil && strings.HasPrefix(strings.ToLower(*value), prefix) {
+			return true
+		}
+	}
+	return false
+}
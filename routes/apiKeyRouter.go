@@ -0,0 +1,22 @@
+package routes
+
+import (
+	controller "golang-restaurant-management/controllers"
+	middlewares "golang-restaurant-management/middleware"
+	"golang-restaurant-management/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ApiKeyRoutes(v1 *gin.RouterGroup, legacy *gin.RouterGroup, apiKeyController *controller.ApiKeyController) {
+	adminOnly := middlewares.Authorization(models.RoleAdmin)
+
+	apiKeys := v1.Group("/api-keys", adminOnly)
+	apiKeys.GET("", apiKeyController.GetApiKeys())
+	apiKeys.POST("", apiKeyController.CreateApiKey())
+	apiKeys.DELETE("/:api_key_id", apiKeyController.RevokeApiKey())
+
+	legacy.GET("/api-keys", middlewares.Successor("/api/v1/api-keys"), adminOnly, apiKeyController.GetApiKeys())
+	legacy.POST("/api-keys", middlewares.Successor("/api/v1/api-keys"), adminOnly, apiKeyController.CreateApiKey())
+	legacy.DELETE("/api-keys/:api_key_id", middlewares.Successor("/api/v1/api-keys/:api_key_id"), adminOnly, apiKeyController.RevokeApiKey())
+}
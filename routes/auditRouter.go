@@ -0,0 +1,17 @@
+package routes
+
+import (
+	controller "golang-restaurant-management/controllers"
+	middlewares "golang-restaurant-management/middleware"
+	"golang-restaurant-management/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AuditRoutes(v1 *gin.RouterGroup, legacy *gin.RouterGroup, auditController *controller.AuditController) {
+	canView := middlewares.Authorization(models.RoleAdmin, models.RoleManager)
+
+	v1.GET("/audit-logs", canView, auditController.GetAuditLogs())
+
+	legacy.GET("/audit", middlewares.Successor("/api/v1/audit-logs"), canView, auditController.GetAuditLogs())
+}
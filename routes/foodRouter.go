@@ -2,13 +2,26 @@ package routes
 
 import (
 	controller "golang-restaurant-management/controllers"
+	middlewares "golang-restaurant-management/middleware"
+	"golang-restaurant-management/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func FoodRoutes(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/foods", controller.GetFoods())
-	incomingRoutes.GET("/foods/:user_id", controller.GetFood())
-	incomingRoutes.POST("/foods", controller.CreateFood())
-	incomingRoutes.PATCH("/foods/:food_id", controller.UpdateFood())
+// FoodRoutes registers the food endpoints. Foods are part of the menu every
+// signed-in account may read, customers without a role included; only
+// changing them needs a role.
+func FoodRoutes(v1 *gin.RouterGroup, legacy *gin.RouterGroup, foodController *controller.FoodController) {
+	canEdit := middlewares.Authorization(models.RoleAdmin, models.RoleManager)
+
+	foods := v1.Group("/foods")
+	foods.GET("", foodController.GetFoods())
+	foods.GET("/:food_id", foodController.GetFood())
+	foods.POST("", canEdit, foodController.CreateFood())
+	foods.PATCH("/:food_id", canEdit, foodController.UpdateFood())
+
+	legacy.GET("/foods", middlewares.Successor("/api/v1/foods"), foodController.GetFoods())
+	legacy.GET("/foods/:food_id", middlewares.Successor("/api/v1/foods/:food_id"), foodController.GetFood())
+	legacy.POST("/foods", middlewares.Successor("/api/v1/foods"), canEdit, foodController.CreateFood())
+	legacy.PATCH("/foods/:food_id", middlewares.Successor("/api/v1/foods/:food_id"), canEdit, foodController.UpdateFood())
 }
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	controller "golang-restaurant-management/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func HealthRoutes(incomingRoutes *gin.Engine, healthController *controller.HealthController) {
+	incomingRoutes.GET("/healthz", healthController.Healthz())
+	incomingRoutes.GET("/readyz", healthController.Readyz())
+	incomingRoutes.GET("/version", healthController.Version())
+}
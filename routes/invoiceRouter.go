@@ -2,14 +2,24 @@ package routes
 
 import (
 	controller "golang-restaurant-management/controllers"
+	middlewares "golang-restaurant-management/middleware"
+	"golang-restaurant-management/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func InvoiceRoutes(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/invoices", controller.GetInvoices())
-	incomingRoutes.GET("/invoices/:invoice_id", controller.GetInvoice())
-	incomingRoutes.POST("/invoices", controller.CreateInvoice())
-	incomingRoutes.PATCH("/invoices/:invoice_id", controller.UpdateInvoice())
+func InvoiceRoutes(v1 *gin.RouterGroup, legacy *gin.RouterGroup, invoiceController *controller.InvoiceController) {
+	canView := middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleCashier, models.RoleWaiter)
+	canEdit := middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleCashier)
 
+	invoices := v1.Group("/invoices")
+	invoices.GET("", canView, invoiceController.GetInvoices())
+	invoices.GET("/:invoice_id", canView, invoiceController.GetInvoice())
+	invoices.POST("", canEdit, invoiceController.CreateInvoice())
+	invoices.PATCH("/:invoice_id", canEdit, invoiceController.UpdateInvoice())
+
+	legacy.GET("/invoices", middlewares.Successor("/api/v1/invoices"), canView, invoiceController.GetInvoices())
+	legacy.GET("/invoices/:invoice_id", middlewares.Successor("/api/v1/invoices/:invoice_id"), canView, invoiceController.GetInvoice())
+	legacy.POST("/invoices", middlewares.Successor("/api/v1/invoices"), canEdit, invoiceController.CreateInvoice())
+	legacy.PATCH("/invoices/:invoice_id", middlewares.Successor("/api/v1/invoices/:invoice_id"), canEdit, invoiceController.UpdateInvoice())
 }
@@ -2,13 +2,26 @@ package routes
 
 import (
 	controller "golang-restaurant-management/controllers"
+	middlewares "golang-restaurant-management/middleware"
+	"golang-restaurant-management/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func MenuRoutes(menuRoutes *gin.Engine) {
-	menuRoutes.GET("/menus", controller.GetMenus())
-	menuRoutes.GET("/menus/:menu_id", controller.GetMenu())
-	menuRoutes.POST("/menu", controller.CreateMenu())
-	menuRoutes.PATCH("/menus/:menu_id", controller.UpdateMenu())
+// MenuRoutes registers the menu endpoints. Menus are readable by every
+// signed-in account, customers without a role included; only changing them
+// needs a role.
+func MenuRoutes(v1 *gin.RouterGroup, legacy *gin.RouterGroup, menuController *controller.MenuController) {
+	canEdit := middlewares.Authorization(models.RoleAdmin, models.RoleManager)
+
+	menus := v1.Group("/menus")
+	menus.GET("", menuController.GetMenus())
+	menus.GET("/:menu_id", menuController.GetMenu())
+	menus.POST("", canEdit, menuController.CreateMenu())
+	menus.PATCH("/:menu_id", canEdit, menuController.UpdateMenu())
+
+	legacy.GET("/menus", middlewares.Successor("/api/v1/menus"), menuController.GetMenus())
+	legacy.GET("/menus/:menu_id", middlewares.Successor("/api/v1/menus/:menu_id"), menuController.GetMenu())
+	legacy.POST("/menu", middlewares.Successor("/api/v1/menus"), canEdit, menuController.CreateMenu())
+	legacy.PATCH("/menus/:menu_id", middlewares.Successor("/api/v1/menus/:menu_id"), canEdit, menuController.UpdateMenu())
 }
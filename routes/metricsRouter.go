@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"golang-restaurant-management/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+func MetricsRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.GET("/metrics", metrics.Handler())
+}
@@ -2,14 +2,27 @@ package routes
 
 import (
 	controller "golang-restaurant-management/controllers"
+	middlewares "golang-restaurant-management/middleware"
+	"golang-restaurant-management/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func OrderItemRoutes(orderItemRoutes *gin.Engine) {
-	orderItemRoutes.GET("/orderItems", controller.GetOrderItems())
-	orderItemRoutes.GET("/orderItems/:orderItem_id", controller.GetOrderItem())
-	orderItemRoutes.GET("/orderItems-order/:order_id", controller.GetOrderItemsByOrder())
-	orderItemRoutes.POST("/orderItem", controller.CreateOrderItem())
-	orderItemRoutes.PATCH("/orderItems/:orderItem_id", controller.UpdateOrderItem())
+func OrderItemRoutes(v1 *gin.RouterGroup, legacy *gin.RouterGroup, orderItemController *controller.OrderItemController) {
+	// self-registered accounts have no role and must not see the floor
+	canView := middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleWaiter, models.RoleChef, models.RoleCashier)
+	canEdit := middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleWaiter, models.RoleChef)
+
+	orderItems := v1.Group("/order-items")
+	orderItems.GET("", canView, orderItemController.GetOrderItems())
+	orderItems.GET("/:order_item_id", canView, orderItemController.GetOrderItem())
+	orderItems.POST("", canEdit, orderItemController.CreateOrderItem())
+	orderItems.PATCH("/:order_item_id", canEdit, orderItemController.UpdateOrderItem())
+	v1.GET("/orders/:order_id/order-items", canView, orderItemController.GetOrderItemsByOrder())
+
+	legacy.GET("/orderItems", middlewares.Successor("/api/v1/order-items"), canView, orderItemController.GetOrderItems())
+	legacy.GET("/orderItems/:order_item_id", middlewares.Successor("/api/v1/order-items/:order_item_id"), canView, orderItemController.GetOrderItem())
+	legacy.GET("/orderItems-order/:order_id", middlewares.Successor("/api/v1/orders/:order_id/order-items"), canView, orderItemController.GetOrderItemsByOrder())
+	legacy.POST("/orderItem", middlewares.Successor("/api/v1/order-items"), canEdit, orderItemController.CreateOrderItem())
+	legacy.PATCH("/orderItems/:order_item_id", middlewares.Successor("/api/v1/order-items/:order_item_id"), canEdit, orderItemController.UpdateOrderItem())
 }
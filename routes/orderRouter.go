@@ -2,13 +2,25 @@ package routes
 
 import (
 	controller "golang-restaurant-management/controllers"
+	middlewares "golang-restaurant-management/middleware"
+	"golang-restaurant-management/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func OrderRoutes(orderRoutes *gin.Engine) {
-	orderRoutes.GET("/orders", controller.GetOrders())
-	orderRoutes.GET("/orders/:order_id", controller.GetOrder())
-	orderRoutes.POST("/order", controller.CreateOrder())
-	orderRoutes.PATCH("/orders/:order_id", controller.UpdateOrder())
+func OrderRoutes(v1 *gin.RouterGroup, legacy *gin.RouterGroup, orderController *controller.OrderController) {
+	// self-registered accounts have no role and must not see the floor
+	canView := middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleWaiter, models.RoleChef, models.RoleCashier)
+	canEdit := middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleWaiter)
+
+	orders := v1.Group("/orders")
+	orders.GET("", canView, orderController.GetOrders())
+	orders.GET("/:order_id", canView, orderController.GetOrder())
+	orders.POST("", canEdit, orderController.CreateOrder())
+	orders.PATCH("/:order_id", canEdit, orderController.UpdateOrder())
+
+	legacy.GET("/orders", middlewares.Successor("/api/v1/orders"), canView, orderController.GetOrders())
+	legacy.GET("/orders/:order_id", middlewares.Successor("/api/v1/orders/:order_id"), canView, orderController.GetOrder())
+	legacy.POST("/order", middlewares.Successor("/api/v1/orders"), canEdit, orderController.CreateOrder())
+	legacy.PATCH("/orders/:order_id", middlewares.Successor("/api/v1/orders/:order_id"), canEdit, orderController.UpdateOrder())
 }
@@ -2,13 +2,26 @@ package routes
 
 import (
 	controller "golang-restaurant-management/controllers"
+	middlewares "golang-restaurant-management/middleware"
+	"golang-restaurant-management/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func TableRoutes(tableRoutes *gin.Engine) {
-	tableRoutes.GET("/tables", controller.GetTables())
-	tableRoutes.GET("/tables/:table_id", controller.GetTable())
-	tableRoutes.POST("/table", controller.CreateTable())
-	tableRoutes.PATCH("/tables/:table_id", controller.UpdateTable())
+func TableRoutes(v1 *gin.RouterGroup, legacy *gin.RouterGroup, tableController *controller.TableController) {
+	// self-registered accounts have no role and must not see the floor
+	canView := middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleWaiter, models.RoleChef, models.RoleCashier)
+	canCreate := middlewares.Authorization(models.RoleAdmin, models.RoleManager)
+	canEdit := middlewares.Authorization(models.RoleAdmin, models.RoleManager, models.RoleWaiter)
+
+	tables := v1.Group("/tables")
+	tables.GET("", canView, tableController.GetTables())
+	tables.GET("/:table_id", canView, tableController.GetTable())
+	tables.POST("", canCreate, tableController.CreateTable())
+	tables.PATCH("/:table_id", canEdit, tableController.UpdateTable())
+
+	legacy.GET("/tables", middlewares.Successor("/api/v1/tables"), canView, tableController.GetTables())
+	legacy.GET("/tables/:table_id", middlewares.Successor("/api/v1/tables/:table_id"), canView, tableController.GetTable())
+	legacy.POST("/table", middlewares.Successor("/api/v1/tables"), canCreate, tableController.CreateTable())
+	legacy.PATCH("/tables/:table_id", middlewares.Successor("/api/v1/tables/:table_id"), canEdit, tableController.UpdateTable())
 }
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	controller "golang-restaurant-management/controllers"
+	middlewares "golang-restaurant-management/middleware"
+	"golang-restaurant-management/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TerminalRoutes(v1 *gin.RouterGroup, legacy *gin.RouterGroup, terminalController *controller.TerminalController) {
+	canManage := middlewares.Authorization(models.RoleAdmin, models.RoleManager)
+
+	terminals := v1.Group("/terminals", canManage)
+	terminals.GET("", terminalController.GetTerminals())
+	terminals.POST("", terminalController.RegisterTerminal())
+	terminals.DELETE("/:terminal_id", terminalController.RevokeTerminal())
+
+	legacy.GET("/terminals", middlewares.Successor("/api/v1/terminals"), canManage, terminalController.GetTerminals())
+	legacy.POST("/terminals", middlewares.Successor("/api/v1/terminals"), canManage, terminalController.RegisterTerminal())
+	legacy.DELETE("/terminals/:terminal_id", middlewares.Successor("/api/v1/terminals/:terminal_id"), canManage, terminalController.RevokeTerminal())
+}
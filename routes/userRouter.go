@@ -2,14 +2,69 @@ package routes
 
 import (
 	controller "golang-restaurant-management/controllers"
+	middlewares "golang-restaurant-management/middleware"
+	"golang-restaurant-management/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func UserRoutes(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/users", controller.GetUsers())
-	incomingRoutes.GET("/users/:user_id", controller.GetUser())
-	incomingRoutes.POST("/user/signup", controller.SignUp())
-	incomingRoutes.POST("/user/login", controller.Login())
-	incomingRoutes.POST("/user/refresh-token", controller.RefreshToken())
+// UserRoutes registers the user endpoints. Most of them are public, so the
+// authentication middleware is passed in and applied route by route.
+func UserRoutes(v1 *gin.RouterGroup, legacy *gin.RouterGroup, userController *controller.UserController, authenticated gin.HandlerFunc) {
+	adminOnly := middlewares.Authorization(models.RoleAdmin)
+
+	users := v1.Group("/users")
+	users.GET("", authenticated, adminOnly, userController.GetUsers())
+	users.GET("/:user_id", authenticated, userController.GetUser())
+	users.POST("/signup", userController.SignUp())
+	users.POST("/login", userController.Login())
+	users.POST("/login/mfa", userController.LoginMfa())
+	users.POST("/pin-login", userController.PinLogin())
+	users.POST("/refresh-token", userController.RefreshToken())
+	users.POST("/mfa/enroll", authenticated, userController.EnrollMfa())
+	users.POST("/mfa/confirm", authenticated, userController.ConfirmMfa())
+	users.POST("/mfa/disable", authenticated, userController.DisableMfa())
+	users.GET("/verify", userController.VerifyEmail())
+	users.POST("/verify/resend", authenticated, userController.ResendEmailVerification())
+	users.POST("/password/forgot", userController.ForgotPassword())
+	users.POST("/password/reset", userController.ResetPassword())
+	users.PATCH("/password", authenticated, userController.ChangePassword())
+	users.PUT("/pin", authenticated, userController.SetPin())
+	users.POST("/logout", authenticated, userController.Logout())
+	users.POST("/logout-all", authenticated, userController.LogoutAll())
+	users.GET("/sessions", authenticated, userController.GetSessions())
+	users.DELETE("/sessions/:session_id", authenticated, userController.RevokeSession())
+	users.PATCH("/:user_id", authenticated, userController.UpdateUser())
+	users.POST("/:user_id/deactivate", authenticated, userController.DeactivateUser())
+	users.POST("/:user_id/reactivate", authenticated, adminOnly, userController.ReactivateUser())
+	users.PATCH("/:user_id/role", authenticated, adminOnly, userController.UpdateUserRole())
+	users.POST("/:user_id/revoke-tokens", authenticated, adminOnly, userController.RevokeUserTokens())
+	users.POST("/:user_id/unlock", authenticated, adminOnly, userController.UnlockUser())
+
+	legacy.GET("/users", middlewares.Successor("/api/v1/users"), authenticated, adminOnly, userController.GetUsers())
+	legacy.GET("/users/:user_id", middlewares.Successor("/api/v1/users/:user_id"), authenticated, userController.GetUser())
+	legacy.POST("/user/signup", middlewares.Successor("/api/v1/users/signup"), userController.SignUp())
+	legacy.POST("/user/login", middlewares.Successor("/api/v1/users/login"), userController.Login())
+	legacy.POST("/user/login/mfa", middlewares.Successor("/api/v1/users/login/mfa"), userController.LoginMfa())
+	legacy.POST("/user/pin-login", middlewares.Successor("/api/v1/users/pin-login"), userController.PinLogin())
+	legacy.POST("/user/refresh-token", middlewares.Successor("/api/v1/users/refresh-token"), userController.RefreshToken())
+	legacy.POST("/user/mfa/enroll", middlewares.Successor("/api/v1/users/mfa/enroll"), authenticated, userController.EnrollMfa())
+	legacy.POST("/user/mfa/confirm", middlewares.Successor("/api/v1/users/mfa/confirm"), authenticated, userController.ConfirmMfa())
+	legacy.POST("/user/mfa/disable", middlewares.Successor("/api/v1/users/mfa/disable"), authenticated, userController.DisableMfa())
+	legacy.GET("/user/verify", middlewares.Successor("/api/v1/users/verify"), userController.VerifyEmail())
+	legacy.POST("/user/verify/resend", middlewares.Successor("/api/v1/users/verify/resend"), authenticated, userController.ResendEmailVerification())
+	legacy.POST("/user/password/forgot", middlewares.Successor("/api/v1/users/password/forgot"), userController.ForgotPassword())
+	legacy.POST("/user/password/reset", middlewares.Successor("/api/v1/users/password/reset"), userController.ResetPassword())
+	legacy.PATCH("/user/password", middlewares.Successor("/api/v1/users/password"), authenticated, userController.ChangePassword())
+	legacy.PUT("/user/pin", middlewares.Successor("/api/v1/users/pin"), authenticated, userController.SetPin())
+	legacy.POST("/user/logout", middlewares.Successor("/api/v1/users/logout"), authenticated, userController.Logout())
+	legacy.POST("/user/logout-all", middlewares.Successor("/api/v1/users/logout-all"), authenticated, userController.LogoutAll())
+	legacy.GET("/user/sessions", middlewares.Successor("/api/v1/users/sessions"), authenticated, userController.GetSessions())
+	legacy.DELETE("/user/sessions/:session_id", middlewares.Successor("/api/v1/users/sessions/:session_id"), authenticated, userController.RevokeSession())
+	legacy.PATCH("/users/:user_id", middlewares.Successor("/api/v1/users/:user_id"), authenticated, userController.UpdateUser())
+	legacy.POST("/users/:user_id/deactivate", middlewares.Successor("/api/v1/users/:user_id/deactivate"), authenticated, userController.DeactivateUser())
+	legacy.POST("/users/:user_id/reactivate", middlewares.Successor("/api/v1/users/:user_id/reactivate"), authenticated, adminOnly, userController.ReactivateUser())
+	legacy.PATCH("/users/:user_id/role", middlewares.Successor("/api/v1/users/:user_id/role"), authenticated, adminOnly, userController.UpdateUserRole())
+	legacy.POST("/users/:user_id/revoke-tokens", middlewares.Successor("/api/v1/users/:user_id/revoke-tokens"), authenticated, adminOnly, userController.RevokeUserTokens())
+	legacy.POST("/users/:user_id/unlock", middlewares.Successor("/api/v1/users/:user_id/unlock"), authenticated, adminOnly, userController.UnlockUser())
 }
@@ -0,0 +1,937 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang-restaurant-management/audit"
+	"golang-restaurant-management/config"
+	controller "golang-restaurant-management/controllers"
+	"golang-restaurant-management/helper"
+	"golang-restaurant-management/mailer"
+	middlewares "golang-restaurant-management/middleware"
+	"golang-restaurant-management/models"
+	"golang-restaurant-management/query"
+	"golang-restaurant-management/repository"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type discardMailer struct{}
+
+func (discardMailer) Send(mailer.Message) error { return nil }
+
+// brokenMailer fails every delivery, like an unreachable SMTP server.
+type brokenMailer struct{}
+
+func (brokenMailer) Send(mailer.Message) error { return errors.New("connection refused") }
+
+// outbox keeps every message it is asked to send.
+type outbox struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+}
+
+func (o *outbox) Send(message mailer.Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.messages = append(o.messages, message)
+	return nil
+}
+
+// lastLink returns the path of the last link mailed to the address.
+func (o *outbox) lastLink(t *testing.T, to string) string {
+	t.Helper()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for i := len(o.messages) - 1; i >= 0; i-- {
+		if o.messages[i].To != to {
+			continue
+		}
+		if link := linkPattern.FindString(o.messages[i].Body); link != "" {
+			return strings.TrimPrefix(link, "http://localhost")
+		}
+	}
+	t.Fatalf("no link mailed to %s", to)
+	return ""
+}
+
+var linkPattern = regexp.MustCompile(`http://localhost/\S+`)
+
+// lastResetCode returns the last password reset code mailed to the address.
+func (o *outbox) lastResetCode(t *testing.T, to string) string {
+	t.Helper()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for i := len(o.messages) - 1; i >= 0; i-- {
+		if o.messages[i].To != to {
+			continue
+		}
+		if match := resetCodePattern.FindStringSubmatch(o.messages[i].Body); match != nil {
+			return match[1]
+		}
+	}
+	t.Fatalf("no reset code mailed to %s", to)
+	return ""
+}
+
+var resetCodePattern = regexp.MustCompile(`reset your password: (\S+)`)
+
+// newTestRouter serves every API route from memory repositories, the way
+// main wires them against MongoDB, and discards account e-mails.
+func newTestRouter(t *testing.T) (*gin.Engine, *repository.Repositories) {
+	t.Helper()
+	return newTestRouterWithMailer(t, discardMailer{})
+}
+
+// newTestRouterWithMailer is newTestRouter with account e-mails sent to mail.
+func newTestRouterWithMailer(t *testing.T, mail mailer.Mailer) (*gin.Engine, *repository.Repositories) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	if err := helper.ConfigureTokens(config.JWT{
+		SecretKey:       "test-secret",
+		AccessTokenTTL:  time.Hour,
+		RefreshTokenTTL: 24 * time.Hour,
+	}); err != nil {
+		t.Fatalf("ConfigureTokens: %v", err)
+	}
+
+	repos := repository.NewMemoryRepositories()
+	recorder := audit.NewRecorder(repos.AuditLogs)
+
+	router := gin.New()
+	router.Use(middlewares.ErrorHandler())
+
+	v1 := router.Group("/api/v1")
+	legacy := router.Group("/", middlewares.Deprecated())
+	authenticated := middlewares.Authentication(repos)
+	UserRoutes(v1, legacy, controller.NewUserController(repos, recorder, mail, "http://localhost"), authenticated)
+
+	for _, group := range []*gin.RouterGroup{v1, legacy} {
+		group.Use(authenticated)
+	}
+
+	FoodRoutes(v1, legacy, controller.NewFoodController(repos.Foods, repos.Menus, recorder))
+	MenuRoutes(v1, legacy, controller.NewMenuController(repos.Menus, recorder))
+	TableRoutes(v1, legacy, controller.NewTableController(repos.Tables, recorder))
+	OrderRoutes(v1, legacy, controller.NewOrderController(repos.Orders, repos.Tables, recorder))
+	OrderItemRoutes(v1, legacy, controller.NewOrderItemController(repos.OrderItems, repos.Orders, recorder))
+	InvoiceRoutes(v1, legacy, controller.NewInvoiceController(repos.Invoices, repos.Orders, repos.OrderItems, recorder))
+	TerminalRoutes(v1, legacy, controller.NewTerminalController(repos.Terminals, recorder))
+	AuditRoutes(v1, legacy, controller.NewAuditController(repos.AuditLogs))
+	ApiKeyRoutes(v1, legacy, controller.NewApiKeyController(repos.ApiKeys, recorder))
+
+	return router, repos
+}
+
+func doJSON(t *testing.T, router *gin.Engine, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatalf("encoding body: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func decodeTokens(t *testing.T, w *httptest.ResponseRecorder) controller.TokenResponse {
+	t.Helper()
+
+	var tokens controller.TokenResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &tokens); err != nil {
+		t.Fatalf("decoding token response %q: %v", w.Body.String(), err)
+	}
+	return tokens
+}
+
+func TestSessionLifecycleWithMemoryRepositories(t *testing.T) {
+	router, repos := newTestRouter(t)
+
+	w := doJSON(t, router, http.MethodPost, "/api/v1/users/signup", "", map[string]string{
+		"first_name": "Ada",
+		"last_name":  "Lovelace",
+		"email":      "ada@example.com",
+		"password":   "secret123",
+		"phone":      "5550100",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("signup: got %d %s, want 201", w.Code, w.Body.String())
+	}
+	signup := decodeTokens(t, w)
+
+	user, err := repos.Users.FindByID(context.Background(), signup.User_id)
+	if err != nil {
+		t.Fatalf("signed up user not stored: %v", err)
+	}
+	if user.Role == nil || *user.Role != "ADMIN" {
+		t.Fatalf("first account role = %v, want ADMIN", user.Role)
+	}
+
+	w = doJSON(t, router, http.MethodPost, "/api/v1/users/login", "", map[string]string{
+		"email":    "ada@example.com",
+		"password": "secret123",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: got %d %s, want 200", w.Code, w.Body.String())
+	}
+	login := decodeTokens(t, w)
+
+	w = doJSON(t, router, http.MethodGet, "/api/v1/users/sessions", login.Token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("sessions: got %d %s, want 200", w.Code, w.Body.String())
+	}
+
+	var sessions query.Page[controller.SessionView]
+	if err := json.Unmarshal(w.Body.Bytes(), &sessions); err != nil {
+		t.Fatalf("decoding sessions %q: %v", w.Body.String(), err)
+	}
+	if sessions.Total != 2 || len(sessions.Items) != 2 {
+		t.Fatalf("sessions: got %d of %d, want the sign-up and login sessions", len(sessions.Items), sessions.Total)
+	}
+
+	w = doJSON(t, router, http.MethodPost, "/api/v1/users/logout", login.Token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("logout: got %d %s, want 200", w.Code, w.Body.String())
+	}
+
+	w = doJSON(t, router, http.MethodGet, "/api/v1/users/sessions", login.Token, nil)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("sessions after logout: got %d %s, want 401", w.Code, w.Body.String())
+	}
+
+	// the sign-up session is independent of the one that logged out
+
+	w = doJSON(t, router, http.MethodGet, "/api/v1/users/sessions", signup.Token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("sign-up session after logout: got %d %s, want 200", w.Code, w.Body.String())
+	}
+
+	w = doJSON(t, router, http.MethodPost, "/api/v1/users/logout-all", signup.Token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("logout-all: got %d %s, want 200", w.Code, w.Body.String())
+	}
+
+	w = doJSON(t, router, http.MethodPost, "/api/v1/users/refresh-token", "", map[string]string{
+		"refresh_token": signup.Refresh_token,
+	})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("refresh after logout-all: got %d %s, want 401", w.Code, w.Body.String())
+	}
+}
+
+func TestLoginFailuresWithMemoryRepositories(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	w := doJSON(t, router, http.MethodPost, "/api/v1/users/signup", "", map[string]string{
+		"first_name": "Ada",
+		"last_name":  "Lovelace",
+		"email":      "ada@example.com",
+		"password":   "secret123",
+		"phone":      "5550100",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("signup: got %d %s, want 201", w.Code, w.Body.String())
+	}
+
+	for _, credentials := range []map[string]string{
+		{"email": "nobody@example.com", "password": "secret123"},
+		{"email": "ada@example.com", "password": "wrong-password"},
+	} {
+		w = doJSON(t, router, http.MethodPost, "/api/v1/users/login", "", credentials)
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("login as %s: got %d %s, want 401", credentials["email"], w.Code, w.Body.String())
+		}
+	}
+
+	locked := false
+	for i := 0; i < 20 && !locked; i++ {
+		w = doJSON(t, router, http.MethodPost, "/api/v1/users/login", "", map[string]string{
+			"email":    "ada@example.com",
+			"password": "wrong-password",
+		})
+		locked = w.Code == http.StatusTooManyRequests
+	}
+	if !locked {
+		t.Fatalf("login never locked after repeated failures, last response %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRefreshTokenRotationWithMemoryRepositories(t *testing.T) {
+	router, repos := newTestRouter(t)
+
+	w := doJSON(t, router, http.MethodPost, "/api/v1/users/signup", "", map[string]string{
+		"first_name": "Ada",
+		"last_name":  "Lovelace",
+		"email":      "ada@example.com",
+		"password":   "secret123",
+		"phone":      "5550100",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("signup: got %d %s, want 201", w.Code, w.Body.String())
+	}
+	signup := decodeTokens(t, w)
+
+	sessions, err := repos.Sessions.ListActive(context.Background(), signup.User_id)
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("sessions after signup: %v, %v", sessions, err)
+	}
+	if sessions[0].Refresh_token_hash != helper.HashToken(signup.Refresh_token) {
+		t.Fatalf("session does not store the hash of the refresh token")
+	}
+
+	w = doJSON(t, router, http.MethodPost, "/api/v1/users/refresh-token", "", map[string]string{
+		"refresh_token": signup.Refresh_token,
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("refresh: got %d %s, want 200", w.Code, w.Body.String())
+	}
+	refreshed := decodeTokens(t, w)
+
+	// presenting the rotated token again ends the whole session
+
+	w = doJSON(t, router, http.MethodPost, "/api/v1/users/refresh-token", "", map[string]string{
+		"refresh_token": signup.Refresh_token,
+	})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("replayed refresh: got %d %s, want 401", w.Code, w.Body.String())
+	}
+
+	w = doJSON(t, router, http.MethodPost, "/api/v1/users/refresh-token", "", map[string]string{
+		"refresh_token": refreshed.Refresh_token,
+	})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("refresh after replay: got %d %s, want 401", w.Code, w.Body.String())
+	}
+}
+
+func TestPinLoginIsLimitedToFloorStaffWithMemoryRepositories(t *testing.T) {
+	router, repos := newTestRouter(t)
+	ctx := context.Background()
+
+	terminalName := "Bar tablet"
+	terminal := models.Terminal{
+		ID:         primitive.NewObjectID(),
+		Name:       &terminalName,
+		Token_hash: helper.HashToken("terminal-token"),
+		Created_at: time.Now(),
+	}
+	terminal.Terminal_id = terminal.ID.Hex()
+	if err := repos.Terminals.Create(ctx, terminal); err != nil {
+		t.Fatalf("creating terminal: %v", err)
+	}
+
+	pin, err := controller.HashPin("4321")
+	if err != nil {
+		t.Fatalf("HashPin: %v", err)
+	}
+
+	var userIds []string
+	for _, email := range []string{"admin@example.com", "waiter@example.com"} {
+		w := doJSON(t, router, http.MethodPost, "/api/v1/users/signup", "", map[string]string{
+			"first_name": "Sam",
+			"last_name":  "Staff",
+			"email":      email,
+			"password":   "secret123",
+			"phone":      email,
+		})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("signup %s: got %d %s, want 201", email, w.Code, w.Body.String())
+		}
+		userId := decodeTokens(t, w).User_id
+		if _, err := repos.Users.Update(ctx, userId, bson.D{{Key: "pin", Value: pin}}); err != nil {
+			t.Fatalf("setting pin: %v", err)
+		}
+		userIds = append(userIds, userId)
+	}
+	adminId, waiterId := userIds[0], userIds[1]
+
+	pinLogin := func(userId string) int {
+		return doJSON(t, router, http.MethodPost, "/api/v1/users/pin-login", "", map[string]string{
+			"terminal_token": "terminal-token",
+			"user_id":        userId,
+			"pin":            "4321",
+		}).Code
+	}
+
+	if code := pinLogin(adminId); code != http.StatusForbidden {
+		t.Errorf("admin pin login: got %d, want 403", code)
+	}
+	if code := pinLogin(waiterId); code != http.StatusForbidden {
+		t.Errorf("pin login without a role: got %d, want 403", code)
+	}
+
+	if _, err := repos.Users.Update(ctx, waiterId, bson.D{{Key: "role", Value: models.RoleWaiter}}); err != nil {
+		t.Fatalf("assigning role: %v", err)
+	}
+	if code := pinLogin(waiterId); code != http.StatusOK {
+		t.Errorf("waiter pin login: got %d, want 200", code)
+	}
+
+	if _, err := repos.Users.Update(ctx, waiterId, bson.D{{Key: "mfa_enabled", Value: true}}); err != nil {
+		t.Fatalf("enabling mfa: %v", err)
+	}
+	if code := pinLogin(waiterId); code != http.StatusForbidden {
+		t.Errorf("pin login with two-factor authentication: got %d, want 403", code)
+	}
+}
+
+func TestAuditLogsRedactSecretHashesWithMemoryRepositories(t *testing.T) {
+	router, repos := newTestRouter(t)
+
+	w := doJSON(t, router, http.MethodPost, "/api/v1/users/signup", "", map[string]string{
+		"first_name": "Ada",
+		"last_name":  "Lovelace",
+		"email":      "ada@example.com",
+		"password":   "secret123",
+		"phone":      "5550100",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("signup: got %d %s, want 201", w.Code, w.Body.String())
+	}
+	admin := decodeTokens(t, w)
+
+	w = doJSON(t, router, http.MethodPost, "/api/v1/terminals", admin.Token, map[string]string{"name": "Bar tablet"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register terminal: got %d %s, want 201", w.Code, w.Body.String())
+	}
+	var terminal controller.TerminalRegistrationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &terminal); err != nil {
+		t.Fatalf("decoding terminal %q: %v", w.Body.String(), err)
+	}
+
+	w = doJSON(t, router, http.MethodDelete, "/api/v1/terminals/"+terminal.Terminal_id, admin.Token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("revoke terminal: got %d %s, want 200", w.Code, w.Body.String())
+	}
+
+	w = doJSON(t, router, http.MethodPost, "/api/v1/api-keys", admin.Token, map[string]interface{}{
+		"name":   "Delivery app",
+		"role":   models.RoleWaiter,
+		"scopes": []string{"orders:read"},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create API key: got %d %s, want 201", w.Code, w.Body.String())
+	}
+	var apiKey controller.ApiKeyCreatedResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &apiKey); err != nil {
+		t.Fatalf("decoding API key %q: %v", w.Body.String(), err)
+	}
+
+	w = doJSON(t, router, http.MethodDelete, "/api/v1/api-keys/"+apiKey.Api_key_id, admin.Token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("revoke API key: got %d %s, want 200", w.Code, w.Body.String())
+	}
+
+	// account changes are recorded too, without the password or secrets
+	userChanges := []struct {
+		method, path string
+		body         interface{}
+	}{
+		{http.MethodPost, "/api/v1/users/" + admin.User_id + "/unlock", nil},
+		{http.MethodPost, "/api/v1/users/" + admin.User_id + "/revoke-tokens", nil},
+	}
+	for _, change := range userChanges {
+		w = doJSON(t, router, change.method, change.path, admin.Token, change.body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s %s: got %d %s, want 200", change.method, change.path, w.Code, w.Body.String())
+		}
+	}
+
+	page, err := repos.AuditLogs.List(context.Background(), query.Params{})
+	if err != nil {
+		t.Fatalf("listing audit logs: %v", err)
+	}
+
+	checked := 0
+	routes := map[string]bool{}
+	for _, entry := range page.Items {
+		routes[entry.Method+" "+entry.Route] = true
+		if entry.Entity == audit.EntityUser {
+			for _, snapshot := range []map[string]interface{}{entry.Before, entry.After} {
+				for _, field := range []string{"password", "pin", "mfa_secret", "mfa_pending_secret", "mfa_recovery_codes"} {
+					if _, ok := snapshot[field]; ok {
+						t.Errorf("user audit entry for %s records %s: %v", entry.Route, field, snapshot)
+					}
+				}
+			}
+			continue
+		}
+		if entry.Entity != audit.EntityTerminal && entry.Entity != audit.EntityApiKey {
+			continue
+		}
+		for _, snapshot := range []map[string]interface{}{entry.Before, entry.After} {
+			for _, field := range []string{"token_hash", "key_hash"} {
+				if _, ok := snapshot[field]; ok {
+					t.Errorf("%s audit entry records %s: %v", entry.Entity, field, snapshot)
+				}
+			}
+		}
+		if entry.Route == "/api/v1/api-keys/:api_key_id" && (entry.Before == nil || entry.After == nil || entry.After["revoked_at"] == nil) {
+			t.Errorf("API key revocation recorded %v before and %v after, want both views", entry.Before, entry.After)
+		}
+		checked++
+	}
+	if checked != 4 {
+		t.Fatalf("found %d terminal and API key audit entries, want 4", checked)
+	}
+	for _, change := range userChanges {
+		if route := change.method + " " + strings.Replace(change.path, admin.User_id, ":user_id", 1); !routes[route] {
+			t.Errorf("no audit entry for %s", route)
+		}
+	}
+}
+
+func TestVerificationLinkOnlyVerifiesTheAddressItWasMailedTo(t *testing.T) {
+	mail := &outbox{}
+	router, repos := newTestRouterWithMailer(t, mail)
+
+	w := doJSON(t, router, http.MethodPost, "/api/v1/users/signup", "", map[string]string{
+		"first_name": "Ada",
+		"last_name":  "Lovelace",
+		"email":      "ada@example.com",
+		"password":   "secret123",
+		"phone":      "5550100",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("signup: got %d %s, want 201", w.Code, w.Body.String())
+	}
+	ada := decodeTokens(t, w)
+	oldLink := mail.lastLink(t, "ada@example.com")
+
+	w = doJSON(t, router, http.MethodPatch, "/api/v1/users/"+ada.User_id, ada.Token, map[string]string{"email": "countess@example.com"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("changing e-mail: got %d %s, want 200", w.Code, w.Body.String())
+	}
+
+	w = doJSON(t, router, http.MethodGet, oldLink, "", nil)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("link mailed to the old address: got %d %s, want 400", w.Code, w.Body.String())
+	}
+
+	user, err := repos.Users.FindByID(context.Background(), ada.User_id)
+	if err != nil {
+		t.Fatalf("fetching user: %v", err)
+	}
+	if user.Email_verified != nil && *user.Email_verified {
+		t.Fatalf("new address verified by a link mailed to the old one")
+	}
+
+	w = doJSON(t, router, http.MethodGet, mail.lastLink(t, "countess@example.com"), "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("link mailed to the new address: got %d %s, want 200", w.Code, w.Body.String())
+	}
+
+	user, err = repos.Users.FindByID(context.Background(), ada.User_id)
+	if err != nil {
+		t.Fatalf("fetching user: %v", err)
+	}
+	if user.Email_verified == nil || !*user.Email_verified {
+		t.Fatalf("new address not verified by its own link")
+	}
+}
+
+func TestInvoiceUpdatesAreValidatedWithMemoryRepositories(t *testing.T) {
+	router, repos := newTestRouter(t)
+
+	w := doJSON(t, router, http.MethodPost, "/api/v1/users/signup", "", map[string]string{
+		"first_name": "Ada",
+		"last_name":  "Lovelace",
+		"email":      "ada@example.com",
+		"password":   "secret123",
+		"phone":      "5550100",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("signup: got %d %s, want 201", w.Code, w.Body.String())
+	}
+	admin := decodeTokens(t, w)
+
+	pending := "PENDING"
+	invoice := models.Invoice{ID: primitive.NewObjectID(), Order_id: "order", Payment_status: &pending}
+	invoice.Invoice_id = invoice.ID.Hex()
+	if _, err := repos.Invoices.Create(context.Background(), invoice); err != nil {
+		t.Fatalf("creating invoice: %v", err)
+	}
+	path := "/api/v1/invoices/" + invoice.Invoice_id
+
+	for _, body := range []map[string]string{
+		{},
+		{"payment_status": "WHATEVER"},
+		{"payment_method": "CHEQUE"},
+	} {
+		w = doJSON(t, router, http.MethodPatch, path, admin.Token, body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("update with %v: got %d %s, want 400", body, w.Code, w.Body.String())
+		}
+	}
+
+	w = doJSON(t, router, http.MethodPatch, path, admin.Token, map[string]string{"payment_status": "PAID", "payment_method": "CARD"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("valid update: got %d %s, want 200", w.Code, w.Body.String())
+	}
+
+	updated, err := repos.Invoices.FindByID(context.Background(), invoice.Invoice_id)
+	if err != nil {
+		t.Fatalf("fetching invoice: %v", err)
+	}
+	if updated.Payment_status == nil || *updated.Payment_status != "PAID" {
+		t.Fatalf("payment_status = %v, want PAID", updated.Payment_status)
+	}
+}
+
+func TestFoodOrderAndTableUpdatesAreValidatedWithMemoryRepositories(t *testing.T) {
+	router, repos := newTestRouter(t)
+	ctx := context.Background()
+
+	w := doJSON(t, router, http.MethodPost, "/api/v1/users/signup", "", map[string]string{
+		"first_name": "Ada",
+		"last_name":  "Lovelace",
+		"email":      "ada@example.com",
+		"password":   "secret123",
+		"phone":      "5550100",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("signup: got %d %s, want 201", w.Code, w.Body.String())
+	}
+	admin := decodeTokens(t, w)
+
+	name, image, menuId, price := "Soup", "soup.png", "menu", 4.0
+	food := models.Food{ID: primitive.NewObjectID(), Name: &name, Food_image: &image, Menu_id: &menuId, Price: &price}
+	food.Food_id = food.ID.Hex()
+	if _, err := repos.Foods.Create(ctx, food); err != nil {
+		t.Fatalf("creating food: %v", err)
+	}
+
+	guests, number := 4, 12
+	table := models.Table{ID: primitive.NewObjectID(), Number_of_guests: &guests, Table_number: &number}
+	table.Table_id = table.ID.Hex()
+	if _, err := repos.Tables.Create(ctx, table); err != nil {
+		t.Fatalf("creating table: %v", err)
+	}
+
+	order := models.Order{ID: primitive.NewObjectID(), Table_id: &table.Table_id, Order_date: time.Now()}
+	order.Order_id = order.ID.Hex()
+	if _, err := repos.Orders.Create(ctx, order); err != nil {
+		t.Fatalf("creating order: %v", err)
+	}
+
+	rejected := []struct {
+		path string
+		body map[string]interface{}
+	}{
+		{"/api/v1/foods/" + food.Food_id, map[string]interface{}{}},
+		{"/api/v1/foods/" + food.Food_id, map[string]interface{}{"price": -1}},
+		{"/api/v1/foods/" + food.Food_id, map[string]interface{}{"name": "X"}},
+		{"/api/v1/orders/" + order.Order_id, map[string]interface{}{}},
+		{"/api/v1/tables/" + table.Table_id, map[string]interface{}{}},
+	}
+	for _, tt := range rejected {
+		w = doJSON(t, router, http.MethodPatch, tt.path, admin.Token, tt.body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("PATCH %s with %v: got %d %s, want 400", tt.path, tt.body, w.Code, w.Body.String())
+		}
+	}
+
+	w = doJSON(t, router, http.MethodPatch, "/api/v1/foods/"+food.Food_id, admin.Token, map[string]interface{}{"price": 4.567})
+	if w.Code != http.StatusOK {
+		t.Fatalf("updating price: got %d %s, want 200", w.Code, w.Body.String())
+	}
+	updated, err := repos.Foods.FindByID(ctx, food.Food_id)
+	if err != nil {
+		t.Fatalf("fetching food: %v", err)
+	}
+	if updated.Price == nil || *updated.Price != 4.57 {
+		t.Fatalf("price = %v, want 4.57", updated.Price)
+	}
+
+	w = doJSON(t, router, http.MethodPatch, "/api/v1/tables/"+table.Table_id, admin.Token, map[string]interface{}{"number_of_guests": 6})
+	if w.Code != http.StatusOK {
+		t.Fatalf("updating table: got %d %s, want 200", w.Code, w.Body.String())
+	}
+}
+
+func TestOrderItemsByOrderWithMemoryRepositories(t *testing.T) {
+	router, repos := newTestRouter(t)
+	ctx := context.Background()
+
+	w := doJSON(t, router, http.MethodPost, "/api/v1/users/signup", "", map[string]string{
+		"first_name": "Ada",
+		"last_name":  "Lovelace",
+		"email":      "ada@example.com",
+		"password":   "secret123",
+		"phone":      "5550100",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("signup: got %d %s, want 201", w.Code, w.Body.String())
+	}
+	admin := decodeTokens(t, w)
+
+	guests, number := 2, 7
+	table := models.Table{ID: primitive.NewObjectID(), Number_of_guests: &guests, Table_number: &number}
+	table.Table_id = table.ID.Hex()
+	if _, err := repos.Tables.Create(ctx, table); err != nil {
+		t.Fatalf("creating table: %v", err)
+	}
+
+	order := models.Order{ID: primitive.NewObjectID(), Table_id: &table.Table_id, Order_date: time.Now()}
+	order.Order_id = order.ID.Hex()
+	if _, err := repos.Orders.Create(ctx, order); err != nil {
+		t.Fatalf("creating order: %v", err)
+	}
+
+	quantity, price := 3, 2.5
+	orderItem := models.OrderItem{ID: primitive.NewObjectID(), Order_id: order.Order_id, Quantity: &quantity, Unit_price: &price}
+	orderItem.OrderItem_id = orderItem.ID.Hex()
+	if _, err := repos.OrderItems.CreateMany(ctx, []models.OrderItem{orderItem}); err != nil {
+		t.Fatalf("creating order item: %v", err)
+	}
+
+	w = doJSON(t, router, http.MethodGet, "/api/v1/orders/"+order.Order_id+"/order-items", admin.Token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("order items: got %d %s, want 200", w.Code, w.Body.String())
+	}
+
+	var page query.Page[controller.OrderItemsSummary]
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decoding order items %q: %v", w.Body.String(), err)
+	}
+	if page.Total != 1 || len(page.Items) != 1 {
+		t.Fatalf("order items = %+v, want one summary", page)
+	}
+
+	summary := page.Items[0]
+	if summary.Total_amount != 7.5 || summary.Total_count != 1 || len(summary.Order_items) != 1 {
+		t.Errorf("summary = %+v, want total 7.5 over one item", summary)
+	}
+	if summary.Table_number == nil || *summary.Table_number != 7 {
+		t.Errorf("table_number = %v, want 7", summary.Table_number)
+	}
+
+	w = doJSON(t, router, http.MethodGet, "/api/v1/orders/unknown/order-items", admin.Token, nil)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unknown order: got %d %s, want 404", w.Code, w.Body.String())
+	}
+}
+
+func TestAccountsWithoutRoleOnlyReadTheMenuWithMemoryRepositories(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	// the first account becomes the admin, the second one has no role
+	var customer controller.TokenResponse
+	for i, email := range []string{"ada@example.com", "guest@example.com"} {
+		w := doJSON(t, router, http.MethodPost, "/api/v1/users/signup", "", map[string]string{
+			"first_name": "Ada",
+			"last_name":  "Lovelace",
+			"email":      email,
+			"password":   "secret123",
+			"phone":      fmt.Sprintf("555010%d", i),
+		})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("signup %s: got %d %s, want 201", email, w.Code, w.Body.String())
+		}
+		customer = decodeTokens(t, w)
+	}
+
+	for _, path := range []string{
+		"/api/v1/orders",
+		"/api/v1/orders/some-order",
+		"/api/v1/orders/some-order/order-items",
+		"/api/v1/order-items",
+		"/api/v1/order-items/some-item",
+		"/api/v1/tables",
+		"/api/v1/tables/some-table",
+		"/api/v1/invoices",
+		"/orders",
+		"/tables",
+	} {
+		if w := doJSON(t, router, http.MethodGet, path, customer.Token, nil); w.Code != http.StatusForbidden {
+			t.Errorf("GET %s without a role: got %d %s, want 403", path, w.Code, w.Body.String())
+		}
+	}
+
+	for _, path := range []string{"/api/v1/menus", "/api/v1/foods"} {
+		if w := doJSON(t, router, http.MethodGet, path, customer.Token, nil); w.Code != http.StatusOK {
+			t.Errorf("GET %s without a role: got %d %s, want 200", path, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestManagersNeedTwoFactorAuthenticationWithMemoryRepositories(t *testing.T) {
+	router, repos := newTestRouter(t)
+	ctx := context.Background()
+
+	var accounts []controller.TokenResponse
+	for i, email := range []string{"ada@example.com", "grace@example.com"} {
+		w := doJSON(t, router, http.MethodPost, "/api/v1/users/signup", "", map[string]string{
+			"first_name": "Ada",
+			"last_name":  "Lovelace",
+			"email":      email,
+			"password":   "secret123",
+			"phone":      fmt.Sprintf("555010%d", i),
+		})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("signup %s: got %d %s, want 201", email, w.Code, w.Body.String())
+		}
+		accounts = append(accounts, decodeTokens(t, w))
+	}
+	admin, manager := accounts[0], accounts[1]
+
+	if _, err := repos.Users.Update(ctx, manager.User_id, bson.D{{Key: "role", Value: models.RoleManager}}); err != nil {
+		t.Fatalf("assigning role: %v", err)
+	}
+	w := doJSON(t, router, http.MethodPost, "/api/v1/users/login", "", map[string]string{
+		"email":    "grace@example.com",
+		"password": "secret123",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("manager login: got %d %s, want 200", w.Code, w.Body.String())
+	}
+	manager = decodeTokens(t, w)
+
+	if w := doJSON(t, router, http.MethodGet, "/api/v1/audit-logs", manager.Token, nil); w.Code != http.StatusForbidden {
+		t.Errorf("manager without two-factor authentication: got %d %s, want 403", w.Code, w.Body.String())
+	}
+	if w := doJSON(t, router, http.MethodPost, "/api/v1/users/mfa/enroll", manager.Token, nil); w.Code != http.StatusOK {
+		t.Errorf("manager enrolling: got %d %s, want 200", w.Code, w.Body.String())
+	}
+
+	// turning two-factor authentication off takes a second factor, not just the password
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	if _, err := repos.Users.Update(ctx, admin.User_id, bson.D{
+		{Key: "mfa_enabled", Value: true},
+		{Key: "mfa_secret", Value: secret},
+	}); err != nil {
+		t.Fatalf("enabling mfa: %v", err)
+	}
+	w = doJSON(t, router, http.MethodPost, "/api/v1/users/mfa/disable", admin.Token, map[string]string{
+		"password": "secret123",
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("disable with only the password: got %d %s, want 400", w.Code, w.Body.String())
+	}
+	w = doJSON(t, router, http.MethodPost, "/api/v1/users/mfa/disable", admin.Token, map[string]string{
+		"password":      "secret123",
+		"recovery_code": "wrong-code",
+	})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("disable with a wrong recovery code: got %d %s, want 401", w.Code, w.Body.String())
+	}
+
+	user, err := repos.Users.FindByID(ctx, admin.User_id)
+	if err != nil {
+		t.Fatalf("finding admin: %v", err)
+	}
+	if user.Mfa_enabled == nil || !*user.Mfa_enabled {
+		t.Errorf("two-factor authentication was turned off without a valid code")
+	}
+}
+
+func TestForgotPasswordWithMemoryRepositories(t *testing.T) {
+	mail := &outbox{}
+	router, _ := newTestRouterWithMailer(t, mail)
+
+	w := doJSON(t, router, http.MethodPost, "/api/v1/users/signup", "", map[string]string{
+		"first_name": "Ada",
+		"last_name":  "Lovelace",
+		"email":      "ada@example.com",
+		"password":   "secret123",
+		"phone":      "5550100",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("signup: got %d %s, want 201", w.Code, w.Body.String())
+	}
+
+	forgot := func(email string) *httptest.ResponseRecorder {
+		return doJSON(t, router, http.MethodPost, "/api/v1/users/password/forgot", "", map[string]string{"email": email})
+	}
+
+	var codes []string
+	for i := 0; i < 2; i++ {
+		if w := forgot("ada@example.com"); w.Code != http.StatusOK {
+			t.Fatalf("forgot password: got %d %s, want 200", w.Code, w.Body.String())
+		}
+		codes = append(codes, mail.lastResetCode(t, "ada@example.com"))
+	}
+
+	// a new request replaces the code mailed before it
+	w = doJSON(t, router, http.MethodPost, "/api/v1/users/password/reset", "", map[string]string{
+		"token":        codes[0],
+		"new_password": "newsecret123",
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("reset with the replaced code: got %d %s, want 400", w.Code, w.Body.String())
+	}
+	w = doJSON(t, router, http.MethodPost, "/api/v1/users/password/reset", "", map[string]string{
+		"token":        codes[1],
+		"new_password": "newsecret123",
+	})
+	if w.Code != http.StatusOK {
+		t.Errorf("reset with the latest code: got %d %s, want 200", w.Code, w.Body.String())
+	}
+
+	// the third request is still answered, the fourth has to wait
+	if w := forgot("ada@example.com"); w.Code != http.StatusOK {
+		t.Fatalf("third forgot password: got %d %s, want 200", w.Code, w.Body.String())
+	}
+	w = forgot("ada@example.com")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("fourth forgot password: got %d %s, want 429 with Retry-After", w.Code, w.Body.String())
+	}
+
+	// unknown addresses are limited the same way
+	for i := 0; i < 3; i++ {
+		forgot("nobody@example.com")
+	}
+	if w := forgot("nobody@example.com"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("forgot password for an unknown address: got %d %s, want 429", w.Code, w.Body.String())
+	}
+}
+
+func TestForgotPasswordHidesMailFailuresWithMemoryRepositories(t *testing.T) {
+	router, _ := newTestRouterWithMailer(t, brokenMailer{})
+
+	w := doJSON(t, router, http.MethodPost, "/api/v1/users/signup", "", map[string]string{
+		"first_name": "Ada",
+		"last_name":  "Lovelace",
+		"email":      "ada@example.com",
+		"password":   "secret123",
+		"phone":      "5550100",
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("signup: got %d %s, want 201", w.Code, w.Body.String())
+	}
+
+	known := doJSON(t, router, http.MethodPost, "/api/v1/users/password/forgot", "", map[string]string{"email": "ada@example.com"})
+	unknown := doJSON(t, router, http.MethodPost, "/api/v1/users/password/forgot", "", map[string]string{"email": "nobody@example.com"})
+
+	if known.Code != http.StatusOK || known.Body.String() != unknown.Body.String() {
+		t.Errorf("forgot password with a failing mailer: got %d %s, want 200 %s", known.Code, known.Body.String(), unknown.Body.String())
+	}
+}
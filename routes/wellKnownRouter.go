@@ -0,0 +1,11 @@
+package routes
+
+import (
+	controller "golang-restaurant-management/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func WellKnownRoutes(incomingRoutes *gin.Engine) {
+	incomingRoutes.GET("/.well-known/jwks.json", controller.GetJWKS())
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang-restaurant-management/config"
+)
+
+// ShutdownHook releases a resource when the server stops. The context is
+// cancelled once the configured shutdown timeout has passed.
+type ShutdownHook func(ctx context.Context) error
+
+type namedHook struct {
+	name string
+	hook ShutdownHook
+}
+
+// Server runs the HTTP server until SIGINT or SIGTERM, then stops accepting
+// connections, waits for in-flight requests to finish and runs the shutdown
+// hooks in reverse order of registration.
+type Server struct {
+	httpServer      *http.Server
+	shutdownTimeout time.Duration
+
+	mu    sync.Mutex
+	hooks []namedHook
+}
+
+func New(cfg config.Server, port string, handler http.Handler) *Server {
+	return &Server{
+		httpServer: &http.Server{
+			Addr:              ":" + port,
+			Handler:           handler,
+			ReadTimeout:       cfg.ReadTimeout,
+			ReadHeaderTimeout: cfg.ReadTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		},
+		shutdownTimeout: cfg.ShutdownTimeout,
+	}
+}
+
+// OnShutdown registers a hook to run after the HTTP server has drained.
+// Hooks run last-registered first, so a worker started after the database
+// connection is stopped before that connection is closed.
+func (s *Server) OnShutdown(name string, hook ShutdownHook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hooks = append(s.hooks, namedHook{name: name, hook: hook})
+}
+
+// Run serves until the process receives SIGINT or SIGTERM or the listener
+// fails, and returns after every shutdown hook has run.
+func (s *Server) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+
+	var err error
+	select {
+	case err = <-serveErr:
+		slog.Error("Server stopped", "error", err)
+	case <-ctx.Done():
+		slog.Info("Shutting down, waiting for requests to finish", "timeout", s.shutdownTimeout.String())
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	if shutdownErr := s.httpServer.Shutdown(shutdownCtx); shutdownErr != nil {
+		slog.Error("Error draining HTTP server", "error", shutdownErr)
+	}
+
+	s.runHooks(shutdownCtx)
+	return err
+}
+
+func (s *Server) runHooks(ctx context.Context) {
+	s.mu.Lock()
+	hooks := s.hooks
+	s.mu.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i].hook(ctx); err != nil {
+			slog.Error("Error in shutdown hook", "hook", hooks[i].name, "error", err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package version
+
+import "runtime/debug"
+
+// Version, Commit and BuildTime are set at build time, e.g.
+//
+//	go build -ldflags "-X golang-restaurant-management/version.Version=1.4.0 \
+//	  -X golang-restaurant-management/version.Commit=$(git rev-parse HEAD) \
+//	  -X golang-restaurant-management/version.BuildTime=$(date -u +%FT%TZ)"
+//
+// When they are not, Commit and BuildTime fall back to the VCS information the
+// Go toolchain embeds in the binary.
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, BuildTime: BuildTime}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	info.GoVersion = build.GoVersion
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildTime == "" {
+				info.BuildTime = setting.Value
+			}
+		}
+	}
+
+	return info
+}